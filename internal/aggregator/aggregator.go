@@ -15,6 +15,7 @@ type Stats struct {
 	EPS          float64        `json:"eps"`
 	LevelCounts  map[string]int64 `json:"level_counts"`
 	DroppedLogs  int64          `json:"dropped_logs"`
+	Truncations  int64          `json:"truncations"`
 	FilesWatched int            `json:"files_watched"`
 }
 
@@ -26,17 +27,20 @@ type Aggregator struct {
 	levelCounts map[string]int64
 	window      []time.Time // timestamps for EPS calculation (last 5 seconds)
 	dropped     func() int64
+	truncations func() int64
 	fileCount   func() int
 	entries     <-chan model.LogEntry
 }
 
 // New creates an Aggregator that reads from the given Hub subscriber channel.
-// droppedFn and fileCountFn provide live values from Hub and Watcher respectively.
-func New(entries <-chan model.LogEntry, droppedFn func() int64, fileCountFn func() int) *Aggregator {
+// droppedFn, truncationsFn and fileCountFn provide live values from Hub, Tailer
+// and Watcher respectively.
+func New(entries <-chan model.LogEntry, droppedFn, truncationsFn func() int64, fileCountFn func() int) *Aggregator {
 	return &Aggregator{
 		startTime:   time.Now(),
 		levelCounts: make(map[string]int64),
 		dropped:     droppedFn,
+		truncations: truncationsFn,
 		fileCount:   fileCountFn,
 		entries:     entries,
 	}
@@ -70,6 +74,7 @@ func (a *Aggregator) Snapshot() Stats {
 		EPS:          eps,
 		LevelCounts:  counts,
 		DroppedLogs:  a.dropped(),
+		Truncations:  a.truncations(),
 		FilesWatched: a.fileCount(),
 	}
 }
@@ -10,7 +10,7 @@ import (
 
 func TestEPSCalculation(t *testing.T) {
 	ch := make(chan model.LogEntry, 100)
-	agg := New(ch, func() int64 { return 0 }, func() int { return 2 })
+	agg := New(ch, func() int64 { return 0 }, func() int64 { return 0 }, func() int { return 2 })
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -38,7 +38,7 @@ func TestEPSCalculation(t *testing.T) {
 
 func TestLevelCounts(t *testing.T) {
 	ch := make(chan model.LogEntry, 100)
-	agg := New(ch, func() int64 { return 0 }, func() int { return 1 })
+	agg := New(ch, func() int64 { return 0 }, func() int64 { return 0 }, func() int { return 1 })
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var catCmd = &cobra.Command{
+	Use:   "cat [paths...]",
+	Short: "Read log files to the end and exit",
+	Long: `Read every matched file (or stdin with "-") from the beginning through
+the parser, level filter and renderer, then exit. Same as
+"loom watch --no-follow". The checkpoint is neither read nor written.
+
+Exit status is 0 when at least one entry was printed, 1 when none
+matched and 2 on errors, so cat can be used in shell pipelines.
+
+Examples:
+  loom cat /var/log/app.log --level error
+  loom cat "/var/log/app.log*" --since 2026-10-16T09:00 --until 2026-10-16T10:00
+  loom cat app.log --tail 500 --output json | jq .message`,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		noFollow = true
+		return runWatch(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(catCmd)
+}
@@ -0,0 +1,428 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/multiline"
+	"github.com/atikulmunna/loom/internal/parser"
+	"github.com/atikulmunna/loom/internal/query"
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// configFlags maps config keys to the persistent flags that override them.
+// Precedence is flags > LOOM_* environment variables > config file > defaults.
+var configFlags = map[string]string{
+	"watch.recursive":          "recursive",
+	"watch.rotate_grace":       "rotate-grace",
+	"watch.source_label":       "source-label",
+	"output.format":            "output",
+	"filter.level":             "level",
+	"filter.where":             "where",
+	"parser.format":            "format",
+	"parser.custom_regex":      "pattern",
+	"parser.pattern_files":     "pattern-file",
+	"parser.timestamp_layouts": "time-layout",
+	"parser.timezone":          "timezone",
+	"parser.json_preset":       "json-preset",
+	"parser.level_field":       "level-field",
+	"parser.message_field":     "message-field",
+	"parser.timestamp_field":   "timestamp-field",
+	"parser.workers":           "parse-workers",
+	"server.enabled":           "serve",
+	"server.port":              "port",
+	"server.history":           "history",
+	"merge.enabled":            "merge",
+	"merge.window":             "merge-window",
+	"merge.late":               "late",
+	"multiline.preset":         "multiline",
+	"multiline.start":          "multiline-start",
+	"multiline.continue":       "multiline-continue",
+	"multiline.max_lines":      "multiline-max-lines",
+	"multiline.timeout":        "multiline-timeout",
+}
+
+// configKeys lists every key Loom reads from the config file.
+var configKeys = map[string]bool{
+	"watch.paths":    true,
+	"parser.sources": true,
+}
+
+// sourceKeys lists the keys allowed in each parser.sources entry.
+var sourceKeys = map[string]bool{
+	"match": true, "format": true, "pattern": true, "pattern_files": true, "json_preset": true,
+	"level_field": true, "message_field": true, "timestamp_field": true,
+	"timestamp_layout": true, "timestamp_layouts": true, "timezone": true,
+}
+
+// bindConfig wires the persistent flags into viper. Flags holding regexes
+// are read directly when set, because viper splits list flags on commas.
+func bindConfig() {
+	for key, name := range configFlags {
+		configKeys[key] = true
+		f := rootCmd.PersistentFlags().Lookup(name)
+		if f.Value.Type() == "stringArray" {
+			continue
+		}
+		cobra.CheckErr(viper.BindPFlag(key, f))
+	}
+}
+
+// stringsSetting resolves a list-valued setting whose flag is not bound.
+func stringsSetting(key string) []string {
+	f := rootCmd.PersistentFlags().Lookup(configFlags[key])
+	if f.Changed {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			return sv.GetSlice()
+		}
+	}
+	return viper.GetStringSlice(key)
+}
+
+// watchSettings is the resolved configuration for a watch run.
+type watchSettings struct {
+	paths       []string
+	recursive   bool
+	grace       time.Duration
+	sourceLabel string
+	output      string
+	levels      string
+	where       *query.Expr
+	format      string
+	pattern     string
+	patterns    []string // grok pattern files
+	timeLayouts []string
+	timezone    string
+	location    *time.Location // --timezone, or UTC
+	jsonPreset  string
+	levelField  string
+	msgField    string
+	timeField   string
+	serve       bool
+	port        string
+	history     int
+	workers     int
+
+	fromBeginning bool
+	tailLines     int
+	since         time.Time
+	until         time.Time
+	noCheckpoint  bool
+	noFollow      bool
+
+	merge       bool
+	mergeWindow time.Duration
+	late        string
+
+	multilinePreset   string
+	multilineStart    []string
+	multilineContinue []string
+	multilineMaxLines int
+	multilineTimeout  time.Duration
+}
+
+// loadSettings merges positional paths, flags, environment and config file.
+func loadSettings(args []string) (watchSettings, error) {
+	s := watchSettings{
+		paths:       args,
+		recursive:   viper.GetBool("watch.recursive"),
+		grace:       viper.GetDuration("watch.rotate_grace"),
+		sourceLabel: viper.GetString("watch.source_label"),
+		output:      viper.GetString("output.format"),
+		levels:      viper.GetString("filter.level"),
+		format:      viper.GetString("parser.format"),
+		pattern:     viper.GetString("parser.custom_regex"),
+		patterns:    stringsSetting("parser.pattern_files"),
+		timeLayouts: stringsSetting("parser.timestamp_layouts"),
+		timezone:    viper.GetString("parser.timezone"),
+		jsonPreset:  viper.GetString("parser.json_preset"),
+		levelField:  viper.GetString("parser.level_field"),
+		msgField:    viper.GetString("parser.message_field"),
+		timeField:   viper.GetString("parser.timestamp_field"),
+		serve:       viper.GetBool("server.enabled"),
+		port:        viper.GetString("server.port"),
+		history:     viper.GetInt("server.history"),
+		workers:     viper.GetInt("parser.workers"),
+
+		merge:       viper.GetBool("merge.enabled"),
+		mergeWindow: viper.GetDuration("merge.window"),
+		late:        strings.ToLower(viper.GetString("merge.late")),
+
+		multilinePreset:   viper.GetString("multiline.preset"),
+		multilineStart:    stringsSetting("multiline.start"),
+		multilineContinue: stringsSetting("multiline.continue"),
+		multilineMaxLines: viper.GetInt("multiline.max_lines"),
+		multilineTimeout:  viper.GetDuration("multiline.timeout"),
+	}
+
+	if s.late != "flag" && s.late != "drop" {
+		return s, fmt.Errorf("--late must be flag or drop, got %q", s.late)
+	}
+	loc, err := timeLocation(s.timezone)
+	if err != nil {
+		return s, err
+	}
+	s.location = loc
+	if w := viper.GetString("filter.where"); w != "" {
+		expr, err := query.ParseIn(w, loc)
+		if err != nil {
+			return s, fmt.Errorf("invalid --where: %w", err)
+		}
+		s.where = expr
+	}
+
+	// Start positions are per invocation, so they are flags only.
+	s.fromBeginning, s.tailLines, s.noCheckpoint, s.noFollow = fromBeginning, tailLines, noCheckpoint, noFollow
+	now := time.Now()
+	if since != "" {
+		t, err := parseTimeFlag("since", since, now, loc)
+		if err != nil {
+			return s, err
+		}
+		s.since = t
+	}
+	if until != "" {
+		t, err := parseTimeFlag("until", until, now, loc)
+		if err != nil {
+			return s, err
+		}
+		s.until = t
+	}
+	set := 0
+	for _, on := range []bool{s.fromBeginning, s.tailLines > 0, !s.since.IsZero()} {
+		if on {
+			set++
+		}
+	}
+	if set > 1 {
+		return s, fmt.Errorf("--from-beginning, --tail and --since cannot be combined")
+	}
+	if s.tailLines < 0 {
+		return s, fmt.Errorf("--tail must not be negative")
+	}
+	if s.history < 0 {
+		return s, fmt.Errorf("--history must not be negative")
+	}
+	if s.workers < 1 {
+		return s, fmt.Errorf("--parse-workers must be at least 1")
+	}
+
+	if len(s.paths) == 0 {
+		s.paths = viper.GetStringSlice("watch.paths")
+	}
+	if len(s.paths) == 0 {
+		return s, fmt.Errorf("no paths to watch: pass them as arguments or set watch.paths in the config file")
+	}
+	s.paths = expandDirs(s.paths, s.recursive)
+	return s, nil
+}
+
+// timeLocation returns the zone that times without an offset are read in,
+// in log lines and in --since, --until and --where alike: --timezone, or
+// UTC when it is not set.
+func timeLocation(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --timezone %q: %w", timezone, err)
+	}
+	return loc, nil
+}
+
+// parseTimeFlag reads a --since or --until value: a duration before now
+// ("15m", "2h") or an absolute time, in loc when it has no offset.
+func parseTimeFlag(name, s string, now time.Time, loc *time.Location) (time.Time, error) {
+	t, err := query.ParseTime(s, now, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s %q: want a duration like 15m or a time like 2026-10-16T09:00", name, s)
+	}
+	return t, nil
+}
+
+// expandDirs turns directory paths into glob patterns for the files inside
+// them, descending into subdirectories when recursive is set.
+func expandDirs(paths []string, recursive bool) []string {
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if info, err := os.Stat(p); err == nil && info.IsDir() {
+			if recursive {
+				p = filepath.Join(p, "**", "*")
+			} else {
+				p = filepath.Join(p, "*")
+			}
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the Loom configuration",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config file for unknown keys, bad patterns and unreachable paths",
+	Long: `Validate the effective configuration (config file, LOOM_* environment
+variables and flags) and report every problem found. Exits non-zero when
+the configuration has problems.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigValidate,
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	if used := viper.ConfigFileUsed(); used != "" {
+		fmt.Fprintf(os.Stderr, "🧵 Validating %s\n", used)
+	} else {
+		fmt.Fprintln(os.Stderr, "🧵 No config file found; validating flags and environment only")
+	}
+
+	problems := validateConfig()
+	if len(problems) == 0 {
+		fmt.Fprintln(os.Stderr, "✅ Configuration OK")
+		return nil
+	}
+
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "   ✗ %s\n", p)
+	}
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	return fmt.Errorf("configuration has %d problem(s)", len(problems))
+}
+
+// validateConfig returns a human-readable description of every problem.
+func validateConfig() []string {
+	var problems []string
+	report := func(format string, a ...any) {
+		problems = append(problems, fmt.Sprintf(format, a...))
+	}
+
+	// Unknown keys (usually typos).
+	for _, key := range viper.AllKeys() {
+		if !configKeys[key] {
+			report("unknown key %q", key)
+		}
+	}
+
+	// Parsers: the global format/regex and every parser.sources entry.
+	if _, err := selectParser(parser.Config{
+		Format:           viper.GetString("parser.format"),
+		Pattern:          viper.GetString("parser.custom_regex"),
+		PatternFiles:     stringsSetting("parser.pattern_files"),
+		TimestampLayouts: stringsSetting("parser.timestamp_layouts"),
+		Timezone:         viper.GetString("parser.timezone"),
+		JSONPreset:       viper.GetString("parser.json_preset"),
+		LevelField:       viper.GetString("parser.level_field"),
+		MessageField:     viper.GetString("parser.message_field"),
+		TimestampField:   viper.GetString("parser.timestamp_field"),
+	}); err != nil {
+		report("parser: %v", err)
+	}
+	var rawSources []map[string]any
+	var sources []sourceParser
+	if err := viper.UnmarshalKey("parser.sources", &rawSources); err != nil {
+		report("parser.sources: %v", err)
+	}
+	_ = viper.UnmarshalKey("parser.sources", &sources)
+	for i, src := range rawSources {
+		var unknown []string
+		for k := range src {
+			if !sourceKeys[k] {
+				unknown = append(unknown, k)
+			}
+		}
+		sort.Strings(unknown)
+		for _, k := range unknown {
+			report("parser.sources[%d]: unknown key %q", i, k)
+		}
+	}
+	for i, src := range sources {
+		if src.Match == "" {
+			report("parser.sources[%d]: match is required", i)
+		}
+		if _, err := parser.New(src.Config); err != nil {
+			report("parser.sources[%d]: %v", i, err)
+		}
+	}
+
+	// Filters.
+	if w := viper.GetString("filter.where"); w != "" {
+		if _, err := query.Parse(w); err != nil {
+			report("filter.where: %v", err)
+		}
+	}
+
+	// Output and server.
+	switch strings.ToLower(viper.GetString("output.format")) {
+	case "text", "json":
+	default:
+		report("output.format: must be text or json, got %q", viper.GetString("output.format"))
+	}
+	if _, err := strconv.ParseUint(viper.GetString("server.port"), 10, 16); err != nil {
+		report("server.port: %q is not a valid port", viper.GetString("server.port"))
+	}
+	if viper.GetInt("server.history") < 0 {
+		report("server.history: must not be negative")
+	}
+	if viper.GetInt("parser.workers") < 1 {
+		report("parser.workers: must be at least 1")
+	}
+
+	// Merge stage.
+	switch strings.ToLower(viper.GetString("merge.late")) {
+	case "flag", "drop":
+	default:
+		report("merge.late: must be flag or drop, got %q", viper.GetString("merge.late"))
+	}
+
+	// Multiline patterns.
+	if _, err := multiline.NewConfig(viper.GetString("multiline.preset"), stringsSetting("multiline.start"),
+		stringsSetting("multiline.continue"), 0, 0); err != nil {
+		report("multiline: %v", err)
+	}
+
+	// Watched paths.
+	_, paths := splitStreams(viper.GetStringSlice("watch.paths"))
+	for _, p := range expandDirs(paths, viper.GetBool("watch.recursive")) {
+		base, _ := doublestar.SplitPattern(filepath.ToSlash(p))
+		if _, err := os.Stat(filepath.FromSlash(base)); err != nil {
+			report("watch.paths: %s: %v", p, err)
+			continue
+		}
+		matches, err := doublestar.FilepathGlob(p, doublestar.WithFilesOnly())
+		if err != nil {
+			report("watch.paths: %s: %v", p, err)
+			continue
+		}
+		if len(matches) == 0 {
+			report("watch.paths: %s matches no files", p)
+		}
+		for _, m := range matches {
+			f, err := os.Open(m)
+			if err != nil {
+				report("watch.paths: %v", err)
+				continue
+			}
+			f.Close()
+		}
+	}
+
+	return problems
+}
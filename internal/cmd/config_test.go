@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// configure resets viper and the flags, then loads yaml as the config file
+// with env and flags on top, as initConfig does for a run.
+func configure(t *testing.T, yaml string, env, flags map[string]string) {
+	t.Helper()
+
+	viper.Reset()
+	rootCmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			_ = sv.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+	bindConfig()
+
+	cfgFile = filepath.Join(t.TempDir(), "loom.yaml")
+	if err := os.WriteFile(cfgFile, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+	for name, v := range flags {
+		if err := rootCmd.PersistentFlags().Set(name, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	initConfig()
+}
+
+func TestLoadSettings(t *testing.T) {
+	dir := t.TempDir()
+	logs := filepath.Join(dir, "*.log")
+	fileConfig := "watch:\n  paths: [" + logs + "]\n" +
+		"parser:\n  format: json\n  timestamp_layouts: [\"02.01.2006\"]\n" +
+		"server:\n  port: \"9000\"\n"
+
+	tests := []struct {
+		name  string
+		yaml  string
+		env   map[string]string
+		flags map[string]string
+		args  []string
+
+		paths       []string
+		format      string
+		port        string
+		mergeWindow time.Duration
+		timeLayouts []string
+		err         string
+	}{
+		{
+			name: "defaults", yaml: "watch:\n  paths: [" + logs + "]\n",
+			paths: []string{logs}, format: "auto", port: "8080", mergeWindow: 2 * time.Second,
+		},
+		{
+			name: "file", yaml: fileConfig,
+			paths: []string{logs}, format: "json", port: "9000", mergeWindow: 2 * time.Second,
+			timeLayouts: []string{"02.01.2006"},
+		},
+		{
+			name: "env over file", yaml: fileConfig,
+			env:   map[string]string{"LOOM_PARSER_FORMAT": "logfmt", "LOOM_MERGE_WINDOW": "5s"},
+			paths: []string{logs}, format: "logfmt", port: "9000", mergeWindow: 5 * time.Second,
+			timeLayouts: []string{"02.01.2006"},
+		},
+		{
+			name: "flags over env", yaml: fileConfig,
+			env:   map[string]string{"LOOM_PARSER_FORMAT": "logfmt", "LOOM_SERVER_PORT": "9100"},
+			flags: map[string]string{"format": "clf", "time-layout": "15:04:05.000"},
+			paths: []string{logs}, format: "clf", port: "9100", mergeWindow: 2 * time.Second,
+			timeLayouts: []string{"15:04:05.000"},
+		},
+		{
+			name: "arguments over watch.paths", yaml: fileConfig, args: []string{"app.log"},
+			paths: []string{"app.log"}, format: "json", port: "9000", mergeWindow: 2 * time.Second,
+			timeLayouts: []string{"02.01.2006"},
+		},
+		{
+			name: "no paths", yaml: "parser:\n  format: json\n",
+			err: "no paths to watch",
+		},
+		{
+			name: "bad where", yaml: fileConfig, flags: map[string]string{"where": "status >="},
+			err: "invalid --where",
+		},
+		{
+			name: "bad timezone", yaml: fileConfig, env: map[string]string{"LOOM_PARSER_TIMEZONE": "Mars/Olympus"},
+			err: "invalid --timezone",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configure(t, tt.yaml, tt.env, tt.flags)
+			s, err := loadSettings(tt.args)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(s.paths, tt.paths) {
+				t.Errorf("paths: expected %v, got %v", tt.paths, s.paths)
+			}
+			if s.format != tt.format || s.port != tt.port || s.mergeWindow != tt.mergeWindow {
+				t.Errorf("expected format %s, port %s, merge window %v; got %s, %s, %v",
+					tt.format, tt.port, tt.mergeWindow, s.format, s.port, s.mergeWindow)
+			}
+			if len(s.timeLayouts) != 0 || len(tt.timeLayouts) != 0 {
+				if !reflect.DeepEqual(s.timeLayouts, tt.timeLayouts) {
+					t.Errorf("time layouts: expected %v, got %v", tt.timeLayouts, s.timeLayouts)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.log"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	logs := filepath.Join(dir, "*.log")
+
+	tests := []struct {
+		name string
+		yaml string
+		env  map[string]string
+		want []string // one substring per problem
+	}{
+		{
+			name: "valid",
+			yaml: "watch:\n  paths: [" + logs + "]\nparser:\n  format: json\n",
+		},
+		{
+			name: "unknown keys",
+			yaml: "watch:\n  paths: [" + logs + "]\nparser:\n  fromat: json\n  sources:\n    - match: \"*.log\"\n      formt: json\n",
+			want: []string{`unknown key "parser.fromat"`, `parser.sources[0]: unknown key "formt"`},
+		},
+		{
+			name: "bad patterns",
+			yaml: "watch:\n  paths: [" + logs + "]\n" +
+				"parser:\n  format: regex\n  custom_regex: \"(\"\n" +
+				"multiline:\n  start: [\"[\"]\n" +
+				"filter:\n  where: \"status >=\"\n",
+			want: []string{"parser:", "multiline: invalid multiline pattern", "filter.where:"},
+		},
+		{
+			name: "source without match",
+			yaml: "watch:\n  paths: [" + logs + "]\nparser:\n  sources:\n    - format: json\n",
+			want: []string{"parser.sources[0]: match is required"},
+		},
+		{
+			name: "unreachable paths",
+			yaml: "watch:\n  paths: [" + filepath.Join(dir, "missing", "*.log") + ", " + filepath.Join(dir, "*.txt") + "]\n",
+			want: []string{"watch.paths: " + filepath.Join(dir, "missing", "*.log"), "matches no files"},
+		},
+		{
+			name: "bad values from env",
+			yaml: "watch:\n  paths: [" + logs + "]\n",
+			env:  map[string]string{"LOOM_SERVER_PORT": "http", "LOOM_MERGE_LATE": "keep"},
+			want: []string{"server.port:", "merge.late:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configure(t, tt.yaml, tt.env, nil)
+			problems := validateConfig()
+			if len(problems) != len(tt.want) {
+				t.Fatalf("expected %d problems, got %q", len(tt.want), problems)
+			}
+			for _, want := range tt.want {
+				found := false
+				for _, p := range problems {
+					if strings.Contains(p, want) {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("expected a problem containing %q, got %q", want, problems)
+				}
+			}
+		})
+	}
+}
@@ -1,9 +1,16 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/atikulmunna/loom/internal/hub"
+	"github.com/atikulmunna/loom/internal/multiline"
+	"github.com/atikulmunna/loom/internal/parser"
+	"github.com/atikulmunna/loom/internal/tailer"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -12,10 +19,40 @@ var (
 	cfgFile     string
 	outputFmt   string
 	levelFilter string
+	where       string
 	format      string
 	pattern     string
+	patternFile []string
 	serve       bool
 	port        string
+	history     int
+	workers     int
+	recursive   bool
+	timeLayouts []string
+	timezone    string
+	jsonPreset  string
+	levelField  string
+	msgField    string
+	timeField   string
+	rotateGrace time.Duration
+	sourceLabel string
+
+	fromBeginning bool
+	tailLines     int
+	since         string
+	until         string
+	noCheckpoint  bool
+	noFollow      bool
+
+	merge       bool
+	mergeWindow time.Duration
+	latePolicy  string
+
+	multilinePreset   string
+	multilineStart    []string
+	multilineContinue []string
+	multilineMaxLines int
+	multilineTimeout  time.Duration
 )
 
 // rootCmd is the base command when called without subcommands.
@@ -27,11 +64,18 @@ It monitors multiple log files, extracts structured data via pattern matching,
 and provides instant observability through your terminal and a live web dashboard.`,
 }
 
-// Execute runs the root command.
+// errNoMatches ends a bulk read that printed no entries.
+var errNoMatches = errors.New("no entries matched")
+
+// Execute runs the root command. The exit status is 0 on success, 1 when a
+// bulk read (--no-follow, loom cat) matched no entries and 2 on errors.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		if errors.Is(err, errNoMatches) {
+			os.Exit(1)
+		}
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(2)
 	}
 }
 
@@ -41,10 +85,42 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file (default: $HOME/.loom.yaml)")
 	rootCmd.PersistentFlags().StringVarP(&outputFmt, "output", "o", "text", "output format: text, json")
 	rootCmd.PersistentFlags().StringVarP(&levelFilter, "level", "l", "", "filter by severity (comma-separated: info,warn,error)")
-	rootCmd.PersistentFlags().StringVarP(&format, "format", "f", "auto", "log format: auto, json, clf, regex")
-	rootCmd.PersistentFlags().StringVarP(&pattern, "pattern", "p", "", "custom regex pattern (used with --format regex)")
+	rootCmd.PersistentFlags().StringVarP(&where, "where", "w", "", `filter expression, e.g. 'level>=WARN and status>=500 and path =~ "^/api"'`)
+	rootCmd.PersistentFlags().StringVarP(&format, "format", "f", "auto", "log format: auto, json, clf, nginx, logfmt, syslog, rfc3164, rfc5424, regex")
+	rootCmd.PersistentFlags().StringVarP(&pattern, "pattern", "p", "", "custom regex or grok pattern (--format regex) or nginx log_format string (--format nginx)")
+	rootCmd.PersistentFlags().StringArrayVar(&patternFile, "pattern-file", nil, "grok pattern file or directory to load (repeatable)")
+	rootCmd.PersistentFlags().StringArrayVar(&timeLayouts, "time-layout", nil, "Go time layout tried before timestamp auto-detection (repeatable)")
+	rootCmd.PersistentFlags().StringVar(&timezone, "timezone", "", "IANA zone for timestamps without an offset, in logs and in --since/--until/--where (default UTC; syslog: local)")
+	rootCmd.PersistentFlags().StringVar(&jsonPreset, "json-preset", "", "JSON logger field mapping: "+strings.Join(parser.JSONPresets(), ", "))
+	rootCmd.PersistentFlags().StringVar(&levelField, "level-field", "", "JSON path holding the level (dotted for nested keys, e.g. log.level)")
+	rootCmd.PersistentFlags().StringVar(&msgField, "message-field", "", "JSON path holding the message")
+	rootCmd.PersistentFlags().StringVar(&timeField, "timestamp-field", "", "JSON path holding the timestamp (e.g. @timestamp)")
+	rootCmd.PersistentFlags().IntVar(&workers, "parse-workers", 1, "goroutines parsing lines in parallel (output order is kept)")
 	rootCmd.PersistentFlags().BoolVarP(&serve, "serve", "s", false, "start the web dashboard")
 	rootCmd.PersistentFlags().StringVar(&port, "port", "8080", "web dashboard port")
+	rootCmd.PersistentFlags().IntVar(&history, "history", 1000, "recent entries the dashboard keeps for new and reconnecting clients")
+	rootCmd.PersistentFlags().BoolVarP(&recursive, "recursive", "r", false, "watch files in subdirectories of directory arguments")
+	rootCmd.PersistentFlags().StringVar(&sourceLabel, "source-label", "stdin", "source label for lines read from stdin (path \"-\")")
+	rootCmd.PersistentFlags().DurationVar(&rotateGrace, "rotate-grace", tailer.DefaultRotateGrace, "keep reading a rotated file for this long after it is renamed")
+
+	rootCmd.PersistentFlags().BoolVar(&fromBeginning, "from-beginning", false, "read files from the start instead of resuming")
+	rootCmd.PersistentFlags().IntVar(&tailLines, "tail", 0, "start at the last N lines of each file")
+	rootCmd.PersistentFlags().StringVar(&since, "since", "", "start at the first line at or after a time (15m, 2026-10-16T09:00)")
+	rootCmd.PersistentFlags().StringVar(&until, "until", "", "stop reading a file at its first line after a time (15m, 2026-10-16T10:00)")
+	rootCmd.PersistentFlags().BoolVar(&noFollow, "no-follow", false, "read files to the end and exit instead of following them")
+	rootCmd.PersistentFlags().BoolVar(&noCheckpoint, "no-checkpoint", false, "neither resume from nor write .loom-state.json")
+
+	rootCmd.PersistentFlags().BoolVar(&merge, "merge", false, "order entries across files by timestamp")
+	rootCmd.PersistentFlags().DurationVar(&mergeWindow, "merge-window", hub.DefaultReorderWindow, "how long --merge holds entries to reorder them")
+	rootCmd.PersistentFlags().StringVar(&latePolicy, "late", "flag", "entries arriving after newer ones were emitted: flag, drop")
+
+	rootCmd.PersistentFlags().StringVar(&multilinePreset, "multiline", "", "join stack traces into one event: generic, java, python, go, node")
+	rootCmd.PersistentFlags().StringArrayVar(&multilineStart, "multiline-start", nil, "regex that marks the first line of an event (repeatable)")
+	rootCmd.PersistentFlags().StringArrayVar(&multilineContinue, "multiline-continue", nil, "regex that marks a continuation line (repeatable)")
+	rootCmd.PersistentFlags().IntVar(&multilineMaxLines, "multiline-max-lines", multiline.DefaultMaxLines, "maximum lines folded into one event")
+	rootCmd.PersistentFlags().DurationVar(&multilineTimeout, "multiline-timeout", multiline.DefaultFlushTimeout, "flush a pending event after this much idle time")
+
+	bindConfig()
 }
 
 func initConfig() {
@@ -60,6 +136,16 @@ func initConfig() {
 		viper.SetConfigType("yaml")
 	}
 
+	// LOOM_PARSER_FORMAT overrides parser.format, and so on.
+	viper.SetEnvPrefix("loom")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
-	_ = viper.ReadInConfig()
+
+	// A missing default config file is fine; a broken or missing explicit one is not.
+	if err := viper.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if cfgFile != "" || !errors.As(err, &notFound) {
+			cobra.CheckErr(fmt.Errorf("failed to read config: %w", err))
+		}
+	}
 }
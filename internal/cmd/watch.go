@@ -8,17 +8,22 @@ import (
 	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/atikulmunna/loom/internal/aggregator"
 	"github.com/atikulmunna/loom/internal/hub"
 	"github.com/atikulmunna/loom/internal/model"
+	"github.com/atikulmunna/loom/internal/multiline"
 	"github.com/atikulmunna/loom/internal/output"
 	"github.com/atikulmunna/loom/internal/parser"
+	"github.com/atikulmunna/loom/internal/query"
 	"github.com/atikulmunna/loom/internal/server"
 	"github.com/atikulmunna/loom/internal/tailer"
 	"github.com/atikulmunna/loom/internal/watcher"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var watchCmd = &cobra.Command{
@@ -26,14 +31,22 @@ var watchCmd = &cobra.Command{
 	Short: "Watch log files for new entries",
 	Long: `Watch one or more log files (or glob patterns) and stream new lines
 to the terminal in real time. Supports colorized output and JSON mode.
+With no arguments, the paths listed under watch.paths in the config
+file are watched.
 
 Examples:
   loom watch /var/log/app.log
   loom watch "/var/log/**/*.log"
   loom watch app.log server.log --output json
   loom watch app.log --format clf
+  loom watch access.log --format nginx --pattern '$remote_addr [$time_local] "$request" $status $request_time'
+  loom watch app.log --multiline java
+  loom watch "/var/log/svc/*.log" --merge --merge-window 2s
+  loom watch app.log --since 15m
+  loom watch app.log --no-follow --level error --until 2026-10-16T10:00
+  kubectl logs -f pod | loom watch - --source-label pod
   loom watch app.log --serve --port 8080`,
-	Args: cobra.MinimumNArgs(1),
+	Args: cobra.ArbitraryArgs,
 	RunE: runWatch,
 }
 
@@ -42,6 +55,11 @@ func init() {
 }
 
 func runWatch(cmd *cobra.Command, args []string) error {
+	cfg, err := loadSettings(args)
+	if err != nil {
+		return err
+	}
+
 	// --- Set up context with graceful shutdown ---
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -54,117 +72,319 @@ func runWatch(cmd *cobra.Command, args []string) error {
 		cancel()
 	}()
 
-	// --- Initialize watcher ---
-	w, err := watcher.New(args)
+	// --- Select parser ---
+	p, err := buildParser(cfg.parserConfig())
 	if err != nil {
-		return fmt.Errorf("failed to create watcher: %w", err)
+		return err
 	}
 
-	watchedPaths := w.Paths()
-	if len(watchedPaths) == 0 {
-		return fmt.Errorf("no files matched the given patterns: %v", args)
-	}
+	// --- Split stdin/FIFO streams from watched file patterns ---
+	streams, patterns := splitStreams(cfg.paths)
+	var sources []<-chan model.RawLine
 
-	fmt.Fprintf(os.Stderr, "🧵 Loom watching %d file(s):\n", len(watchedPaths))
-	for _, p := range watchedPaths {
-		fmt.Fprintf(os.Stderr, "   • %s\n", p)
-	}
-	fmt.Fprintln(os.Stderr)
+	// --- Initialize watcher and tailer for files ---
+	var w *watcher.Watcher
+	var t *tailer.Tailer
+	if len(patterns) > 0 {
+		w, err = watcher.New(patterns)
+		if err != nil {
+			return fmt.Errorf("failed to create watcher: %w", err)
+		}
 
-	// --- Initialize checkpoint ---
-	ckptPath := filepath.Join(".", ".loom-state.json")
-	ckpt, err := tailer.NewCheckpoint(ckptPath)
-	if err != nil {
-		return fmt.Errorf("failed to load checkpoint: %w", err)
+		watchedPaths := w.Paths()
+		if len(watchedPaths) == 0 {
+			return fmt.Errorf("no files matched the given patterns: %v", patterns)
+		}
+
+		fmt.Fprintf(os.Stderr, "🧵 Loom watching %d file(s):\n", len(watchedPaths))
+		for _, p := range watchedPaths {
+			fmt.Fprintf(os.Stderr, "   • %s\n", p)
+		}
+		fmt.Fprintln(os.Stderr)
+
+		var ckpt *tailer.Checkpoint
+		if !cfg.noCheckpoint && !cfg.noFollow {
+			ckptPath := filepath.Join(".", ".loom-state.json")
+			ckpt, err = tailer.NewCheckpoint(ckptPath)
+			if err != nil {
+				return fmt.Errorf("failed to load checkpoint: %w", err)
+			}
+		}
+
+		t = tailer.New(w, ckpt, tailer.Options{
+			RotateGrace:   cfg.grace,
+			FromBeginning: cfg.fromBeginning || cfg.noFollow && cfg.tailLines == 0 && cfg.since.IsZero(),
+			TailLines:     cfg.tailLines,
+			Since:         cfg.since,
+			Until:         cfg.until,
+			NoFollow:      cfg.noFollow,
+			Merge:         cfg.merge,
+			Timestamp:     lineTime(p),
+		})
+		sources = append(sources, t.Lines())
 	}
 
-	// --- Initialize tailer ---
-	t := tailer.New(w, ckpt)
+	// --- Initialize streams (no watcher, no checkpoint) ---
+	var readers []*tailer.Stream
+	for _, path := range streams {
+		var st *tailer.Stream
+		if path == "-" {
+			st = tailer.NewStream(os.Stdin, cfg.sourceLabel)
+		} else {
+			st = tailer.NewFIFOStream(path, path)
+		}
+		fmt.Fprintf(os.Stderr, "🧵 Loom reading %s\n", st.Source())
+		readers = append(readers, st)
+		sources = append(sources, st.Lines())
+	}
 
-	// --- Select parser ---
-	p, err := selectParser(format, pattern)
-	if err != nil {
-		return err
+	// --- Assemble multi-line events (stack traces) if requested ---
+	lines := fanIn(sources)
+	if cfg.multilineEnabled() {
+		mlCfg, err := multiline.NewConfig(cfg.multilinePreset, cfg.multilineStart, cfg.multilineContinue, cfg.multilineMaxLines, cfg.multilineTimeout)
+		if err != nil {
+			return err
+		}
+		asm := multiline.New(lines, mlCfg)
+		go asm.Start(ctx)
+		lines = asm.Lines()
 	}
 
 	// --- Initialize hub ---
-	h := hub.New(t.Lines(), p)
+	// Recent history is only kept for the dashboard.
+	opts := hub.Options{
+		Merge:         cfg.merge,
+		ReorderWindow: cfg.mergeWindow,
+		DropLate:      cfg.late == "drop",
+		Workers:       cfg.workers,
+	}
+	if cfg.serve {
+		opts.History = cfg.history
+	}
+	h := hub.New(lines, p, opts)
 
 	// --- Choose renderer ---
 	var renderer output.Renderer
-	switch strings.ToLower(outputFmt) {
+	switch strings.ToLower(cfg.output) {
 	case "json":
 		renderer = output.NewJSONRenderer()
 	default:
 		renderer = output.NewTextRenderer()
 	}
 
-	// --- Build level filter set ---
+	// --- Build level filter set (--where is applied on top) ---
 	levelSet := make(map[string]bool)
-	if levelFilter != "" {
-		for _, l := range strings.Split(levelFilter, ",") {
+	if cfg.levels != "" {
+		for _, l := range strings.Split(cfg.levels, ",") {
 			levelSet[strings.ToUpper(strings.TrimSpace(l))] = true
 		}
 	}
 
 	// --- Subscribe CLI to hub ---
-	cliEntries := h.Subscribe()
+	// Bulk reads must deliver every line, so the CLI and the aggregator make
+	// the hub wait for them. When following, a slow terminal loses the newest
+	// lines and the aggregator samples.
+	cliPolicy, aggPolicy := hub.DropNewest, hub.Sample
+	if cfg.noFollow {
+		cliPolicy, aggPolicy = hub.Block, hub.Block
+	}
+	cliSub := h.Subscribe("cli", cliPolicy)
 
 	// --- Start web server if --serve is set ---
-	if serve {
+	if cfg.serve {
 		// Aggregator subscribes to hub.
-		aggEntries := h.Subscribe()
-		agg := aggregator.New(aggEntries, h.Dropped, func() int { return len(watchedPaths) })
+		aggEntries := h.Subscribe("aggregator", aggPolicy).Entries()
+		truncations, fileCount := func() int64 { return 0 }, func() int { return 0 }
+		if t != nil {
+			truncations, fileCount = t.Truncations, func() int { return len(w.Paths()) }
+		}
+		agg := aggregator.New(aggEntries, h.Dropped, truncations, fileCount)
 		go agg.Start(ctx)
 
 		// Start web server.
-		srv := server.New(h, agg, port)
+		srv := server.New(h, agg, cfg.port)
+		srv.SetLocation(cfg.location)
 		go func() {
-			fmt.Fprintf(os.Stderr, "🌐 Dashboard running at http://localhost:%s\n\n", port)
+			fmt.Fprintf(os.Stderr, "🌐 Dashboard running at http://localhost:%s\n\n", cfg.port)
 			if err := srv.Start(); err != nil {
 				log.Printf("server error: %v", err)
 			}
 		}()
 	}
 
-	// --- Start pipeline: Watcher → Tailer → Hub ---
-	go w.Start(ctx)
-	go t.Start(ctx)
+	// --- Start pipeline: Watcher → Tailer / Streams → Hub ---
+	if t != nil {
+		if !cfg.noFollow {
+			go w.Start(ctx)
+		}
+		go t.Start(ctx)
+	}
+	for _, st := range readers {
+		go st.Start(ctx)
+	}
 	go h.Start(ctx)
 
 	// --- Render CLI output ---
-	for entry := range cliEntries {
-		if shouldShow(entry, levelSet) {
+	matched := 0
+	for entry := range cliSub.Entries() {
+		if shouldShow(entry, levelSet, cfg.where) {
+			matched++
 			if err := renderer.Render(entry); err != nil {
 				log.Printf("render error: %v", err)
 			}
 		}
 	}
 
+	// Piped input has ended; keep the dashboard up until interrupted.
+	if cfg.serve && ctx.Err() == nil {
+		fmt.Fprintln(os.Stderr, "🧵 Input ended; dashboard still running (Ctrl+C to exit)")
+		<-ctx.Done()
+	}
+
+	// Like grep, a bulk read that printed nothing exits with status 1.
+	if cfg.noFollow && matched == 0 && ctx.Err() == nil {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return errNoMatches
+	}
 	return nil
 }
 
+// splitStreams separates stdin ("-") and named pipes from file patterns.
+func splitStreams(paths []string) (streams, patterns []string) {
+	for _, p := range paths {
+		if p == "-" || tailer.IsFIFO(p) {
+			streams = append(streams, p)
+		} else {
+			patterns = append(patterns, p)
+		}
+	}
+	return streams, patterns
+}
+
+// fanIn merges several line sources into one channel, which is closed once
+// every source is exhausted.
+func fanIn(sources []<-chan model.RawLine) <-chan model.RawLine {
+	if len(sources) == 1 {
+		return sources[0]
+	}
+
+	out := make(chan model.RawLine, 512)
+	var wg sync.WaitGroup
+	for _, src := range sources {
+		wg.Add(1)
+		go func(src <-chan model.RawLine) {
+			defer wg.Done()
+			for raw := range src {
+				out <- raw
+			}
+		}(src)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// sourceParser is one entry of parser.sources in the config file: a glob
+// plus the parser settings for files that match it.
+type sourceParser struct {
+	Match         string `mapstructure:"match"`
+	parser.Config `mapstructure:",squash"`
+}
+
+// buildParser creates the parser for the pipeline: the --format parser, or a
+// router that dispatches on source when parser.sources is configured.
+// Sources without their own timestamp, JSON mapping or grok pattern file
+// settings inherit the global ones.
+func buildParser(global parser.Config) (parser.Parser, error) {
+	fallback, err := selectParser(global)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []sourceParser
+	if err := viper.UnmarshalKey("parser.sources", &sources); err != nil {
+		return nil, fmt.Errorf("invalid parser.sources: %w", err)
+	}
+	if len(sources) == 0 {
+		return fallback, nil
+	}
+
+	routes := make([]parser.Route, 0, len(sources))
+	for i, src := range sources {
+		if src.Match == "" {
+			return nil, fmt.Errorf("parser.sources[%d]: match is required", i)
+		}
+		if src.TimestampLayout == "" && len(src.TimestampLayouts) == 0 {
+			src.TimestampLayouts = global.TimestampLayouts
+		}
+		if src.Timezone == "" {
+			src.Timezone = global.Timezone
+		}
+		if len(src.PatternFiles) == 0 {
+			src.PatternFiles = global.PatternFiles
+		}
+		if src.JSONPreset == "" && src.LevelField == "" && src.MessageField == "" && src.TimestampField == "" {
+			src.JSONPreset = global.JSONPreset
+			src.LevelField, src.MessageField, src.TimestampField = global.LevelField, global.MessageField, global.TimestampField
+		}
+		p, err := parser.New(src.Config)
+		if err != nil {
+			return nil, fmt.Errorf("parser.sources[%d] (%s): %w", i, src.Match, err)
+		}
+		glob := src.Match
+		if strings.ContainsAny(glob, `/\`) {
+			glob, _ = filepath.Abs(glob)
+		}
+		routes = append(routes, parser.Route{Glob: glob, Parser: p})
+	}
+	return parser.NewRouter(routes, fallback), nil
+}
+
 // selectParser creates the appropriate parser based on CLI flags.
-func selectParser(format, pattern string) (parser.Parser, error) {
-	switch strings.ToLower(format) {
-	case "json":
-		return parser.NewJSONParser(), nil
-	case "clf":
-		return parser.NewCLFParser(), nil
-	case "regex":
-		if pattern == "" {
-			return nil, fmt.Errorf("--pattern is required when using --format regex")
-		}
-		return parser.NewRegexParser(pattern)
-	default:
-		return parser.NewAutoParser(), nil
+func selectParser(cfg parser.Config) (parser.Parser, error) {
+	if strings.ToLower(cfg.Format) == "regex" && cfg.Pattern == "" {
+		return nil, fmt.Errorf("--pattern is required when using --format regex")
 	}
+	return parser.New(cfg)
+}
+
+// parserConfig returns the global parser settings.
+func (s watchSettings) parserConfig() parser.Config {
+	return parser.Config{
+		Format:           s.format,
+		Pattern:          s.pattern,
+		PatternFiles:     s.patterns,
+		TimestampLayouts: s.timeLayouts,
+		Timezone:         s.timezone,
+		JSONPreset:       s.jsonPreset,
+		LevelField:       s.levelField,
+		MessageField:     s.msgField,
+		TimestampField:   s.timeField,
+	}
+}
+
+// lineTime returns a function extracting a line's timestamp with the
+// pipeline parser, for --since, --until and --merge of whole files.
+func lineTime(p parser.Parser) func(line, source string) (time.Time, bool) {
+	return func(line, source string) (time.Time, bool) {
+		entry := p.Parse(line, source)
+		return entry.Timestamp, entry.TimeParsed
+	}
+}
+
+// multilineEnabled reports whether any multiline option was given.
+func (s watchSettings) multilineEnabled() bool {
+	return s.multilinePreset != "" || len(s.multilineStart) > 0 || len(s.multilineContinue) > 0
 }
 
-// shouldShow returns true if the entry passes the level filter.
-func shouldShow(entry model.LogEntry, levelSet map[string]bool) bool {
-	if len(levelSet) == 0 {
-		return true // no filter = show all
+// shouldShow returns true if the entry passes the level filter and the
+// --where expression.
+func shouldShow(entry model.LogEntry, levelSet map[string]bool, where *query.Expr) bool {
+	if len(levelSet) > 0 && !levelSet[entry.Level] {
+		return false
 	}
-	return levelSet[entry.Level]
+	return where == nil || where.Match(entry)
 }
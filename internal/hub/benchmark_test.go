@@ -16,11 +16,11 @@ func BenchmarkHubBroadcast10(b *testing.B) { benchHubBroadcast(b, 10) }
 
 func benchHubBroadcast(b *testing.B, numSubs int) {
 	input := make(chan model.RawLine, b.N+1)
-	h := New(input, parser.NewAutoParser())
+	h := New(input, parser.NewAutoParser(), Options{})
 
 	// Create subscribers and drain them.
 	for i := 0; i < numSubs; i++ {
-		ch := h.Subscribe()
+		ch := h.Subscribe(fmt.Sprintf("bench %d", i), DropNewest).Entries()
 		go func() {
 			for range ch {
 			}
@@ -43,3 +43,43 @@ func benchHubBroadcast(b *testing.B, numSubs int) {
 
 	cancel()
 }
+
+// BenchmarkHubParseWorkers measures end-to-end throughput — JSON lines in,
+// entries out to one subscriber — by number of parse workers. Speedup is
+// bounded by GOMAXPROCS.
+func BenchmarkHubParseWorkers1(b *testing.B) { benchParseWorkers(b, 1) }
+func BenchmarkHubParseWorkers2(b *testing.B) { benchParseWorkers(b, 2) }
+func BenchmarkHubParseWorkers4(b *testing.B) { benchParseWorkers(b, 4) }
+func BenchmarkHubParseWorkers8(b *testing.B) { benchParseWorkers(b, 8) }
+
+func benchParseWorkers(b *testing.B, workers int) {
+	lines := make([]model.RawLine, 1024)
+	for i := range lines {
+		lines[i] = model.RawLine{
+			Text:   fmt.Sprintf(`{"level":"info","msg":"request %d done","time":"2026-10-17T09:00:%02dZ","http":{"method":"GET","status":200,"path":"/api/items/%d"},"dur_ms":%d.5}`, i, i%60, i, i%300),
+			Source: fmt.Sprintf("app%d.log", i%4),
+		}
+	}
+
+	input := make(chan model.RawLine, 4096)
+	h := New(input, parser.NewAutoParser(), Options{Workers: workers})
+	sub := h.Subscribe("bench", Block)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.Start(ctx)
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	go func() {
+		for i := 0; i < b.N; i++ {
+			input <- lines[i%len(lines)]
+		}
+		close(input)
+	}()
+	for i := 0; i < b.N; i++ {
+		<-sub.Entries()
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "lines/s")
+}
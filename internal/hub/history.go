@@ -0,0 +1,58 @@
+package hub
+
+import "github.com/atikulmunna/loom/internal/model"
+
+// history keeps the most recent broadcast entries in a ring, so a new
+// subscriber can be backfilled and a reconnecting one can resume by Seq.
+// Seqs in the ring are consecutive, which makes lookups index arithmetic.
+type history struct {
+	entries []model.LogEntry
+	start   int // index of the oldest entry
+	n       int // number of entries held
+}
+
+func newHistory(size int) *history {
+	return &history{entries: make([]model.LogEntry, size)}
+}
+
+// add records an entry, evicting the oldest when the ring is full.
+func (r *history) add(entry model.LogEntry) {
+	size := len(r.entries)
+	if size == 0 {
+		return
+	}
+	if r.n < size {
+		r.entries[(r.start+r.n)%size] = entry
+		r.n++
+		return
+	}
+	r.entries[r.start] = entry
+	r.start = (r.start + 1) % size
+}
+
+// since returns the held entries with Seq after seq, oldest first, and how
+// many entries after seq have already been evicted. A seq of 0, or one
+// newer than anything held (the client saw an earlier run of Loom), returns
+// everything held.
+func (r *history) since(seq uint64) (entries []model.LogEntry, missed uint64) {
+	if r.n == 0 {
+		return nil, 0
+	}
+	oldest := r.entries[r.start].Seq
+	newest := oldest + uint64(r.n) - 1
+
+	skip := 0
+	switch {
+	case seq == 0 || seq > newest:
+	case seq < oldest:
+		missed = oldest - seq - 1
+	default:
+		skip = int(seq - oldest + 1)
+	}
+
+	entries = make([]model.LogEntry, 0, r.n-skip)
+	for i := skip; i < r.n; i++ {
+		entries = append(entries, r.entries[(r.start+i)%len(r.entries)])
+	}
+	return entries, missed
+}
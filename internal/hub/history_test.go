@@ -0,0 +1,53 @@
+package hub
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/atikulmunna/loom/internal/model"
+)
+
+func seqs(entries []model.LogEntry) []uint64 {
+	out := make([]uint64, len(entries))
+	for i, e := range entries {
+		out[i] = e.Seq
+	}
+	return out
+}
+
+func TestHistorySince(t *testing.T) {
+	r := newHistory(3)
+	if got, missed := r.since(0); len(got) != 0 || missed != 0 {
+		t.Fatalf("empty history returned %v, missed %d", seqs(got), missed)
+	}
+	for seq := uint64(1); seq <= 5; seq++ {
+		r.add(model.LogEntry{Seq: seq})
+	}
+
+	tests := []struct {
+		since  uint64
+		want   []uint64
+		missed uint64
+	}{
+		{0, []uint64{3, 4, 5}, 0}, // new client: everything held
+		{1, []uint64{3, 4, 5}, 1}, // 2 was evicted
+		{2, []uint64{3, 4, 5}, 0}, // resumes exactly at the oldest
+		{4, []uint64{5}, 0},       // resumes mid-ring
+		{5, []uint64{}, 0},        // up to date
+		{9, []uint64{3, 4, 5}, 0}, // seq from an earlier run
+	}
+	for _, tt := range tests {
+		got, missed := r.since(tt.since)
+		if !reflect.DeepEqual(seqs(got), tt.want) || missed != tt.missed {
+			t.Errorf("since(%d) = %v, missed %d; want %v, missed %d", tt.since, seqs(got), missed, tt.want, tt.missed)
+		}
+	}
+}
+
+func TestHistoryDisabled(t *testing.T) {
+	r := newHistory(0)
+	r.add(model.LogEntry{Seq: 1})
+	if got, _ := r.since(0); len(got) != 0 {
+		t.Errorf("expected no history, got %v", seqs(got))
+	}
+}
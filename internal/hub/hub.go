@@ -2,8 +2,10 @@ package hub
 
 import (
 	"context"
-	"log"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/atikulmunna/loom/internal/model"
 	"github.com/atikulmunna/loom/internal/parser"
@@ -11,38 +13,112 @@ import (
 
 const subscriberBuffer = 1024
 
+// Options configures a Hub. What happens to entries for slow consumers is
+// chosen per subscription; see Policy.
+type Options struct {
+	// Merge orders entries across sources by Timestamp, holding each for
+	// up to ReorderWindow (DefaultReorderWindow when zero). Entries older
+	// than ones already emitted are flagged Late, or dropped with DropLate.
+	Merge         bool
+	ReorderWindow time.Duration
+	DropLate      bool
+
+	// History is how many recent entries to keep for SubscribeSince.
+	History int
+
+	// Workers is how many goroutines parse lines (one when zero). Entries
+	// are broadcast in input order whatever the number.
+	Workers int
+}
+
 // Hub receives raw lines, parses them, and broadcasts LogEntry values to all subscribers.
 type Hub struct {
-	parser      parser.Parser
-	input       <-chan model.RawLine
+	parser  parser.Parser
+	input   <-chan model.RawLine
+	opts    Options
+	dropped atomic.Int64
+
+	// mu guards the fields below. It is never held while sending, so a
+	// blocked subscriber cannot hold up callers such as Dropped.
 	mu          sync.RWMutex
-	subscribers []chan model.LogEntry
-	dropped     int64
+	subscribers []*Subscription // copied on write; broadcast sends to a snapshot
+	seq         uint64          // Seq of the last broadcast entry
+	history     *history
 }
 
 // New creates a Hub that reads from the input channel and parses with the given parser.
-func New(input <-chan model.RawLine, p parser.Parser) *Hub {
+func New(input <-chan model.RawLine, p parser.Parser, opts Options) *Hub {
 	return &Hub{
-		parser: p,
-		input:  input,
+		parser:  p,
+		input:   input,
+		opts:    opts,
+		history: newHistory(opts.History),
 	}
 }
 
-// Subscribe returns a buffered channel that will receive parsed log entries.
-// Multiple consumers can subscribe; each gets a copy of every entry.
-func (h *Hub) Subscribe() <-chan model.LogEntry {
-	ch := make(chan model.LogEntry, subscriberBuffer)
+// Subscribe returns a subscription that will receive parsed log entries on
+// a buffered channel. Multiple consumers can subscribe; each gets a copy of
+// every entry. The name identifies the consumer in Subscribers, and the
+// policy says what happens when it falls behind.
+func (h *Hub) Subscribe(name string, policy Policy) *Subscription {
+	sub := newSubscription(h, name, policy)
 	h.mu.Lock()
-	h.subscribers = append(h.subscribers, ch)
+	h.subscribers = append(h.subscribers, sub)
 	h.mu.Unlock()
-	return ch
+	return sub
 }
 
-// Dropped returns the total number of entries dropped due to slow consumers.
-func (h *Hub) Dropped() int64 {
+// Backfill is the recent history handed to a subscriber by SubscribeSince.
+type Backfill struct {
+	Entries []model.LogEntry // oldest first
+	Missed  uint64           // entries after the requested Seq that are no longer kept
+	Last    uint64           // Seq of the last entry before the channel's first
+}
+
+// SubscribeSince is Subscribe with a backfill of the kept history: every
+// entry after since (0 for all of it) is in exactly one of the backfill and
+// the channel, so a client resuming from its last seen Seq gets no gaps or
+// duplicates while the entries are still kept.
+func (h *Hub) SubscribeSince(name string, policy Policy, since uint64) (*Subscription, Backfill) {
+	sub := newSubscription(h, name, policy)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var b Backfill
+	b.Entries, b.Missed = h.history.since(since)
+	b.Last = h.seq
+	h.subscribers = append(h.subscribers, sub)
+	return sub, b
+}
+
+// Subscribers returns the stats of the current subscriptions.
+func (h *Hub) Subscribers() []SubscriberStats {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
-	return h.dropped
+	stats := make([]SubscriberStats, len(h.subscribers))
+	for i, sub := range h.subscribers {
+		stats[i] = sub.Stats()
+	}
+	return stats
+}
+
+// remove drops a closed subscription and closes its channel.
+func (h *Hub) remove(sub *Subscription) {
+	h.mu.Lock()
+	for i, s := range h.subscribers {
+		if s == sub {
+			subs := make([]*Subscription, 0, len(h.subscribers)-1)
+			subs = append(subs, h.subscribers[:i]...)
+			h.subscribers = append(subs, h.subscribers[i+1:]...)
+			break
+		}
+	}
+	h.mu.Unlock()
+	sub.closeChannel()
+}
+
+// Dropped returns the total number of entries dropped due to slow consumers.
+func (h *Hub) Dropped() int64 {
+	return h.dropped.Load()
 }
 
 // Start begins reading from the input channel, parsing, and broadcasting.
@@ -50,42 +126,105 @@ func (h *Hub) Dropped() int64 {
 func (h *Hub) Start(ctx context.Context) {
 	defer h.closeAll()
 
+	batches := h.parseLines(ctx)
+	if h.opts.Merge {
+		h.startMerged(ctx, batches)
+		return
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case raw, ok := <-h.input:
+		case entries, ok := <-batches:
 			if !ok {
 				return
 			}
-			entry := h.parser.Parse(raw.Text, raw.Source)
-			h.broadcast(entry)
+			h.broadcastAll(entries)
 		}
 	}
 }
 
-// broadcast sends an entry to all subscribers.
-// If a subscriber's channel is full, the entry is dropped for that subscriber.
-func (h *Hub) broadcast(entry model.LogEntry) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+// startMerged is Start with the merge stage between parsing and broadcast.
+// When the input ends, everything still held is released in order.
+func (h *Hub) startMerged(ctx context.Context, batches <-chan []model.LogEntry) {
+	window := h.opts.ReorderWindow
+	if window <= 0 {
+		window = DefaultReorderWindow
+	}
+	m := newMerger(window, h.opts.DropLate)
 
-	for _, ch := range h.subscribers {
+	tick := window / 4
+	if tick < minReleaseTick {
+		tick = minReleaseTick
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
 		select {
-		case ch <- entry:
-		default:
-			h.dropped++
-			log.Printf("hub: dropped entry for slow consumer (total dropped: %d)", h.dropped)
+		case <-ctx.Done():
+			return
+		case entries, ok := <-batches:
+			if !ok {
+				h.broadcastAll(m.flush())
+				return
+			}
+			now := time.Now()
+			for _, e := range entries {
+				h.broadcastAll(m.add(e, now))
+			}
+		case now := <-ticker.C:
+			h.broadcastAll(m.release(now))
+		}
+	}
+}
+
+// parse converts a raw line into a LogEntry. Multi-line events (assembled
+// stack traces) are parsed by their first line and keep the full text in Raw.
+func (h *Hub) parse(raw model.RawLine) model.LogEntry {
+	first, _, multi := strings.Cut(raw.Text, "\n")
+	if !multi {
+		return h.parser.Parse(raw.Text, raw.Source)
+	}
+	entry := h.parser.Parse(first, raw.Source)
+	entry.Raw = raw.Text
+	return entry
+}
+
+// broadcast numbers an entry, records it in the history and sends it to all
+// subscribers, each according to its Policy. Numbering, recording and
+// taking the subscriber list happen together, so SubscribeSince sees each
+// entry in exactly one of its backfill and its channel; the sends happen
+// after unlocking.
+func (h *Hub) broadcast(entry model.LogEntry) {
+	h.mu.Lock()
+	h.seq++
+	entry.Seq = h.seq
+	h.history.add(entry)
+	subs := h.subscribers
+	h.mu.Unlock()
+
+	for _, sub := range subs {
+		if sub.deliver(entry) {
+			h.dropped.Add(1)
 		}
 	}
 }
 
+// broadcastAll broadcasts entries in order.
+func (h *Hub) broadcastAll(entries []model.LogEntry) {
+	for _, e := range entries {
+		h.broadcast(e)
+	}
+}
+
 // closeAll closes all subscriber channels.
 func (h *Hub) closeAll() {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	for _, ch := range h.subscribers {
-		close(ch)
+	for _, sub := range h.subscribers {
+		sub.closeChannel()
 	}
 	h.subscribers = nil
 }
@@ -5,16 +5,17 @@ import (
 	"testing"
 	"time"
 
+	"github.com/atikulmunna/loom/internal/aggregator"
 	"github.com/atikulmunna/loom/internal/model"
 	"github.com/atikulmunna/loom/internal/parser"
 )
 
 func TestHubBroadcast(t *testing.T) {
 	input := make(chan model.RawLine, 10)
-	h := New(input, parser.NewAutoParser())
+	h := New(input, parser.NewAutoParser(), Options{})
 
-	sub1 := h.Subscribe()
-	sub2 := h.Subscribe()
+	sub1 := h.Subscribe("one", DropNewest).Entries()
+	sub2 := h.Subscribe("two", DropNewest).Entries()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -48,10 +49,10 @@ func TestHubBroadcast(t *testing.T) {
 
 func TestHubSlowConsumer(t *testing.T) {
 	input := make(chan model.RawLine, 10)
-	h := New(input, parser.NewAutoParser())
+	h := New(input, parser.NewAutoParser(), Options{})
 
 	// Subscribe but never read — simulates a slow consumer.
-	_ = h.Subscribe()
+	slow := h.Subscribe("slow", DropNewest)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -69,6 +70,138 @@ func TestHubSlowConsumer(t *testing.T) {
 	if h.Dropped() == 0 {
 		t.Error("expected dropped entries for slow consumer, got 0")
 	}
+	if stats := slow.Stats(); stats.Dropped != h.Dropped() || stats.Delivered != subscriberBuffer {
+		t.Errorf("unexpected subscriber stats %+v", stats)
+	}
 
 	cancel()
 }
+
+func TestHubMultilineEvent(t *testing.T) {
+	input := make(chan model.RawLine, 1)
+	h := New(input, parser.NewAutoParser(), Options{})
+	sub := h.Subscribe("test", DropNewest).Entries()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go h.Start(ctx)
+
+	text := "ERROR request failed\n\tat com.example.Foo.bar(Foo.java:12)"
+	input <- model.RawLine{Text: text, Source: "app.log"}
+
+	select {
+	case e := <-sub:
+		if e.Message != "ERROR request failed" {
+			t.Errorf("expected first line as message, got %q", e.Message)
+		}
+		if e.Raw != text {
+			t.Errorf("expected full text in raw, got %q", e.Raw)
+		}
+		if e.Level != "ERROR" {
+			t.Errorf("expected ERROR, got %s", e.Level)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("timed out")
+	}
+}
+
+// A window too short to divide into a tick must not panic.
+func TestHubMergeTinyWindow(t *testing.T) {
+	input := make(chan model.RawLine, 1)
+	h := New(input, parser.NewAutoParser(), Options{Merge: true, ReorderWindow: time.Nanosecond})
+	sub := h.Subscribe("test", DropNewest).Entries()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.Start(ctx)
+
+	input <- model.RawLine{Text: "INFO merged", Source: "test.log"}
+	select {
+	case e := <-sub:
+		if e.Raw != "INFO merged" {
+			t.Errorf("unexpected entry %q", e.Raw)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+}
+
+func TestHubSubscribeSince(t *testing.T) {
+	input := make(chan model.RawLine, 10)
+	h := New(input, parser.NewAutoParser(), Options{History: 3})
+	live := h.Subscribe("live", DropNewest).Entries()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.Start(ctx)
+
+	for _, msg := range []string{"one", "two", "three", "four"} {
+		input <- model.RawLine{Text: "INFO " + msg, Source: "test.log"}
+	}
+	for want := uint64(1); want <= 4; want++ {
+		select {
+		case e := <-live:
+			if e.Seq != want {
+				t.Fatalf("expected seq %d, got %d", want, e.Seq)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	// Resume after seq 2: three and four are backfilled, five arrives live.
+	sub, backfill := h.SubscribeSince("resumed", DropNewest, 2)
+	if got := seqs(backfill.Entries); len(got) != 2 || got[0] != 3 || got[1] != 4 || backfill.Missed != 0 || backfill.Last != 4 {
+		t.Fatalf("unexpected backfill %v, missed %d", got, backfill.Missed)
+	}
+	input <- model.RawLine{Text: "INFO five", Source: "test.log"}
+	select {
+	case e := <-sub.Entries():
+		if e.Seq != 5 || e.Raw != "INFO five" {
+			t.Errorf("expected five (seq 5), got %q (seq %d)", e.Raw, e.Seq)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	// Seq 1 has been evicted from a history of 3 (now 3, 4, 5).
+	if _, backfill := h.SubscribeSince("new", DropNewest, 0); backfill.Missed != 0 || len(backfill.Entries) != 3 {
+		t.Errorf("new subscriber: got %v, missed %d", seqs(backfill.Entries), backfill.Missed)
+	}
+	if _, backfill := h.SubscribeSince("old", DropNewest, 1); backfill.Missed != 1 {
+		t.Errorf("expected 1 missed entry, got %d", backfill.Missed)
+	}
+}
+
+// A blocked broadcast must not hold up Dropped: the aggregator calls it
+// from Snapshot while its consumer waits to record the next entry, as with
+// --no-follow --serve.
+func TestHubBlockedBroadcastDoesNotHoldDropped(t *testing.T) {
+	const lines = 3 * subscriberBuffer
+	input := make(chan model.RawLine, lines)
+	h := New(input, parser.NewAutoParser(), Options{})
+	sub := h.Subscribe("stats", Block)
+	agg := aggregator.New(sub.Entries(), h.Dropped,
+		func() int64 { return 0 }, func() int { return 1 })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.Start(ctx)
+	go agg.Start(ctx)
+
+	for i := 0; i < lines; i++ {
+		input <- model.RawLine{Text: "INFO line", Source: "test.log"}
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for agg.Snapshot().TotalEvents < lines {
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Snapshot deadlocked with a blocked broadcast")
+	}
+}
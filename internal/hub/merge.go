@@ -0,0 +1,123 @@
+package hub
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/model"
+)
+
+// DefaultReorderWindow is the reorder window used when merging is enabled
+// without an explicit window.
+const DefaultReorderWindow = 2 * time.Second
+
+// minReleaseTick bounds how often held entries are checked, however short
+// the window.
+const minReleaseTick = time.Millisecond
+
+// merger orders entries from several sources by Timestamp. Entries are held
+// until the watermark (the newest timestamp seen minus the window) passes
+// them, or until they have waited the window in wall-clock time, so a quiet
+// stream never holds entries back for long. An entry whose timestamp was not
+// parsed sorts right after the previous entry from the same source.
+type merger struct {
+	window   time.Duration
+	dropLate bool
+	last     map[string]time.Time // last parsed timestamp per source
+
+	pending  entryHeap
+	seq      uint64
+	newest   time.Time // newest timestamp seen
+	released time.Time // newest timestamp released
+}
+
+func newMerger(window time.Duration, dropLate bool) *merger {
+	return &merger{window: window, dropLate: dropLate, last: make(map[string]time.Time)}
+}
+
+// add queues an entry and returns any entries that can be released. An
+// entry older than what has already been released is late: it is returned
+// at once with Late set, or dropped when dropLate is set.
+func (m *merger) add(entry model.LogEntry, now time.Time) []model.LogEntry {
+	key := entry.Timestamp
+	if entry.TimeParsed {
+		m.last[entry.Source] = key
+	} else if t, ok := m.last[entry.Source]; ok {
+		key = t
+	}
+
+	if key.Before(m.released) {
+		if m.dropLate {
+			return m.release(now)
+		}
+		entry.Late = true
+		return append([]model.LogEntry{entry}, m.release(now)...)
+	}
+
+	m.seq++
+	heap.Push(&m.pending, heldEntry{entry: entry, key: key, arrived: now, seq: m.seq})
+	if key.After(m.newest) {
+		m.newest = key
+	}
+	return m.release(now)
+}
+
+// release pops the entries that are behind the watermark or have waited
+// out the window, in timestamp order.
+func (m *merger) release(now time.Time) []model.LogEntry {
+	watermark := m.newest.Add(-m.window)
+	var out []model.LogEntry
+	for len(m.pending) > 0 {
+		top := m.pending[0]
+		if top.key.After(watermark) && now.Sub(top.arrived) < m.window {
+			break
+		}
+		out = append(out, m.pop())
+	}
+	return out
+}
+
+// flush releases everything still held, in timestamp order.
+func (m *merger) flush() []model.LogEntry {
+	var out []model.LogEntry
+	for len(m.pending) > 0 {
+		out = append(out, m.pop())
+	}
+	return out
+}
+
+func (m *merger) pop() model.LogEntry {
+	held := heap.Pop(&m.pending).(heldEntry)
+	if held.key.After(m.released) {
+		m.released = held.key
+	}
+	return held.entry
+}
+
+// heldEntry is an entry waiting in the reorder window.
+type heldEntry struct {
+	entry   model.LogEntry
+	key     time.Time // sort key: Timestamp, or the source's last parsed one
+	arrived time.Time
+	seq     uint64 // keeps arrival order for equal timestamps
+}
+
+// entryHeap is a min-heap of held entries by timestamp.
+type entryHeap []heldEntry
+
+func (h entryHeap) Len() int { return len(h) }
+func (h entryHeap) Less(i, j int) bool {
+	if !h[i].key.Equal(h[j].key) {
+		return h[i].key.Before(h[j].key)
+	}
+	return h[i].seq < h[j].seq
+}
+func (h entryHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *entryHeap) Push(x any)   { *h = append(*h, x.(heldEntry)) }
+func (h *entryHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
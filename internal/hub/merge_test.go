@@ -0,0 +1,93 @@
+package hub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/model"
+)
+
+func messages(entries []model.LogEntry) []string {
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = e.Message
+		if e.Late {
+			out[i] += " (late)"
+		}
+	}
+	return out
+}
+
+func TestMergerOrdersWithinWindow(t *testing.T) {
+	base := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	now := time.Now()
+	m := newMerger(2*time.Second, false)
+
+	var got []model.LogEntry
+	add := func(msg string, offset time.Duration) {
+		got = append(got, m.add(model.LogEntry{Message: msg, Timestamp: base.Add(offset), TimeParsed: true}, now)...)
+	}
+
+	add("b", 1*time.Second)
+	add("a", 0)
+	add("c", 1500*time.Millisecond)
+	if len(got) != 0 {
+		t.Fatalf("expected entries to be held inside the window, got %v", messages(got))
+	}
+
+	// A newer entry moves the watermark past a and b.
+	add("e", 3500*time.Millisecond)
+	got = append(got, m.flush()...)
+
+	want := []string{"a", "b", "c", "e"}
+	if g := messages(got); len(g) != len(want) || g[0] != "a" || g[1] != "b" || g[2] != "c" || g[3] != "e" {
+		t.Errorf("expected %v, got %v", want, g)
+	}
+}
+
+func TestMergerReleasesAfterWallClockWindow(t *testing.T) {
+	now := time.Now()
+	m := newMerger(2*time.Second, false)
+
+	if got := m.add(model.LogEntry{Message: "quiet", Timestamp: now}, now); len(got) != 0 {
+		t.Fatalf("expected entry to be held, got %v", messages(got))
+	}
+	if got := m.release(now.Add(2 * time.Second)); len(got) != 1 {
+		t.Errorf("expected entry to be released after the window, got %v", messages(got))
+	}
+}
+
+func TestMergerLateEntries(t *testing.T) {
+	base := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	now := time.Now()
+
+	for _, drop := range []bool{false, true} {
+		m := newMerger(time.Second, drop)
+		m.add(model.LogEntry{Message: "a", Timestamp: base.Add(10 * time.Second), TimeParsed: true}, now)
+		m.flush()
+
+		got := m.add(model.LogEntry{Message: "old", Timestamp: base, TimeParsed: true}, now)
+		switch {
+		case drop && len(got) != 0:
+			t.Errorf("expected late entry to be dropped, got %v", messages(got))
+		case !drop && (len(got) != 1 || !got[0].Late):
+			t.Errorf("expected late entry to be flagged, got %v", messages(got))
+		}
+	}
+}
+
+func TestMergerKeepsUnparsedWithSource(t *testing.T) {
+	base := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	now := time.Now()
+	m := newMerger(2*time.Second, false)
+
+	m.add(model.LogEntry{Message: "a", Source: "a.log", Timestamp: base.Add(time.Second), TimeParsed: true}, now)
+	// A stack trace line: stamped with the ingest time, far in the future.
+	m.add(model.LogEntry{Message: "a trace", Source: "a.log", Timestamp: now}, now)
+	m.add(model.LogEntry{Message: "b", Source: "b.log", Timestamp: base.Add(2 * time.Second), TimeParsed: true}, now)
+
+	got := messages(m.flush())
+	if len(got) != 3 || got[0] != "a" || got[1] != "a trace" || got[2] != "b" {
+		t.Errorf("expected [a, a trace, b], got %v", got)
+	}
+}
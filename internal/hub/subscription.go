@@ -0,0 +1,221 @@
+package hub
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/model"
+)
+
+// Policy says what the hub does when a subscriber's channel is full.
+type Policy int
+
+const (
+	// DropNewest discards the incoming entry. This is the default.
+	DropNewest Policy = iota
+	// DropOldest discards the oldest queued entry to make room, so a
+	// live view stays current.
+	DropOldest
+	// Block waits for the subscriber, for sinks that must not lose
+	// entries. A blocked subscriber holds up every other one.
+	Block
+	// Sample delivers every SampleEvery-th entry once the channel is half
+	// full, and drops the rest, for consumers that only need a trend.
+	Sample
+)
+
+// SampleEvery is the rate the Sample policy keeps under pressure.
+const SampleEvery = 10
+
+// dropWarnInterval is the least time between drop warnings for one
+// subscriber.
+const dropWarnInterval = 10 * time.Second
+
+var policyNames = [...]string{"drop-newest", "drop-oldest", "block", "sample"}
+
+func (p Policy) String() string {
+	if p >= 0 && int(p) < len(policyNames) {
+		return policyNames[p]
+	}
+	return "unknown"
+}
+
+// ParsePolicy parses a policy name: drop-newest, drop-oldest, block or
+// sample.
+func ParsePolicy(s string) (Policy, error) {
+	for i, name := range policyNames {
+		if strings.EqualFold(s, name) {
+			return Policy(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown backpressure policy %q (available: %s)", s, strings.Join(policyNames[:], ", "))
+}
+
+// Subscription is a consumer's handle on the hub. Entries arrive on
+// Entries until the hub stops or the subscription is closed; a consumer
+// that goes away must Close its subscription so the hub stops sending to it.
+type Subscription struct {
+	hub    *Hub
+	name   string
+	policy Policy
+	ch     chan model.LogEntry
+
+	done     chan struct{} // closed by Close; releases a blocked send
+	stopOnce sync.Once
+	sendMu   sync.Mutex // held while sending, so ch is not closed under a send
+	closed   bool       // ch is closed; guarded by sendMu
+
+	delivered atomic.Int64
+	dropped   atomic.Int64
+
+	// Owned by the broadcasting goroutine.
+	pressured int       // entries seen under pressure, for Sample
+	unwarned  int64     // drops since the last warning
+	lastWarn  time.Time // time of the last warning
+}
+
+// SubscriberStats describes one subscription.
+type SubscriberStats struct {
+	Name      string `json:"name"`
+	Policy    string `json:"policy"`
+	Delivered int64  `json:"delivered"`
+	Dropped   int64  `json:"dropped"`
+	Queued    int    `json:"queued"` // entries waiting in the channel
+}
+
+func newSubscription(h *Hub, name string, policy Policy) *Subscription {
+	return &Subscription{
+		hub:    h,
+		name:   name,
+		policy: policy,
+		ch:     make(chan model.LogEntry, subscriberBuffer),
+		done:   make(chan struct{}),
+	}
+}
+
+// Entries returns the channel entries are delivered on. It is closed when
+// the hub stops or the subscription is closed.
+func (s *Subscription) Entries() <-chan model.LogEntry {
+	return s.ch
+}
+
+// Name returns the name given to Subscribe.
+func (s *Subscription) Name() string {
+	return s.name
+}
+
+// Stats returns the subscription's delivery counters.
+func (s *Subscription) Stats() SubscriberStats {
+	return SubscriberStats{
+		Name:      s.name,
+		Policy:    s.policy.String(),
+		Delivered: s.delivered.Load(),
+		Dropped:   s.dropped.Load(),
+		Queued:    len(s.ch),
+	}
+}
+
+// Close removes the subscription from the hub and closes its channel.
+// It is safe to call more than once, and after the hub has stopped.
+func (s *Subscription) Close() {
+	s.stopOnce.Do(func() { close(s.done) })
+	s.hub.remove(s)
+}
+
+// Unsubscribe is Close.
+func (s *Subscription) Unsubscribe() {
+	s.Close()
+}
+
+// deliver hands an entry to the subscriber according to its policy and
+// reports whether an entry (this one or, for DropOldest, a queued one) was
+// dropped. Only the broadcasting goroutine calls it.
+func (s *Subscription) deliver(entry model.LogEntry) bool {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	if s.closed {
+		return false
+	}
+
+	switch s.policy {
+	case Block:
+		select {
+		case s.ch <- entry:
+			s.delivered.Add(1)
+		case <-s.done:
+		}
+		return false
+
+	case DropOldest:
+		select {
+		case s.ch <- entry:
+			s.delivered.Add(1)
+			return false
+		default:
+		}
+		// Make room; the consumer may have made some meanwhile.
+		dropped := false
+		select {
+		case <-s.ch:
+			s.delivered.Add(-1)
+			dropped = true
+		default:
+		}
+		select {
+		case s.ch <- entry:
+			s.delivered.Add(1)
+		default:
+			dropped = true
+		}
+		if dropped {
+			s.drop()
+		}
+		return dropped
+
+	case Sample:
+		if len(s.ch) >= cap(s.ch)/2 {
+			s.pressured++
+			if s.pressured%SampleEvery != 1 {
+				s.drop()
+				return true
+			}
+		} else {
+			s.pressured = 0
+		}
+	}
+
+	select {
+	case s.ch <- entry:
+		s.delivered.Add(1)
+		return false
+	default:
+		s.drop()
+		return true
+	}
+}
+
+// drop counts a dropped entry and warns, at most every dropWarnInterval.
+func (s *Subscription) drop() {
+	total := s.dropped.Add(1)
+	s.unwarned++
+	if now := time.Now(); now.Sub(s.lastWarn) >= dropWarnInterval {
+		log.Printf("hub: dropped %d entries for slow consumer %q (%s; %d in total)", s.unwarned, s.name, s.policy, total)
+		s.unwarned = 0
+		s.lastWarn = now
+	}
+}
+
+// closeChannel closes the entry channel once, waiting for a send in
+// progress. Close has already released a blocked one.
+func (s *Subscription) closeChannel() {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	if !s.closed {
+		close(s.ch)
+		s.closed = true
+	}
+}
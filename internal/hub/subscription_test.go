@@ -0,0 +1,174 @@
+package hub
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/model"
+	"github.com/atikulmunna/loom/internal/parser"
+)
+
+func TestSubscriptionClose(t *testing.T) {
+	input := make(chan model.RawLine, 10)
+	h := New(input, parser.NewAutoParser(), Options{})
+	keep := h.Subscribe("keep", DropNewest)
+	gone := h.Subscribe("gone", DropNewest)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.Start(ctx)
+
+	gone.Close()
+	gone.Unsubscribe() // a second close is harmless
+	if _, ok := <-gone.Entries(); ok {
+		t.Error("expected the closed subscription's channel to be closed")
+	}
+	if subs := h.Subscribers(); len(subs) != 1 || subs[0].Name != "keep" {
+		t.Fatalf("expected only keep to remain, got %+v", subs)
+	}
+
+	// Lines keep flowing to the others, and nothing is dropped for the
+	// closed subscription.
+	for i := 0; i < 3; i++ {
+		input <- model.RawLine{Text: "INFO line", Source: "test.log"}
+		select {
+		case <-keep.Entries():
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+	if h.Dropped() != 0 {
+		t.Errorf("expected no drops, got %d", h.Dropped())
+	}
+	if stats := keep.Stats(); stats.Delivered != 3 || stats.Dropped != 0 || stats.Queued != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// Closing after the hub has stopped is safe too.
+	cancel()
+	for range keep.Entries() {
+	}
+	keep.Close()
+}
+
+func TestSubscriptionCloseReleasesBlockedHub(t *testing.T) {
+	input := make(chan model.RawLine, subscriberBuffer+10)
+	h := New(input, parser.NewAutoParser(), Options{})
+	stuck := h.Subscribe("stuck", Block)
+	live := h.Subscribe("live", Block)
+
+	received := make(chan int)
+	go func() {
+		n := 0
+		for range live.Entries() {
+			n++
+		}
+		received <- n
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.Start(ctx)
+
+	// Fill stuck's buffer so the hub blocks on it.
+	for i := 0; i < subscriberBuffer+5; i++ {
+		input <- model.RawLine{Text: "INFO line", Source: "test.log"}
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		stuck.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close deadlocked with a blocked broadcast")
+	}
+
+	// The hub carries on; every line still reaches live.
+	close(input)
+	if n := <-received; n != subscriberBuffer+5 {
+		t.Errorf("live received %d entries, expected %d", n, subscriberBuffer+5)
+	}
+}
+
+func TestSubscriptionPolicies(t *testing.T) {
+	const sent = subscriberBuffer + 100
+	tests := []struct {
+		policy      Policy
+		queued      int
+		first, last uint64 // Seq of the first and last queued entries
+		dropped     int64
+	}{
+		{DropNewest, subscriberBuffer, 1, subscriberBuffer, 100},
+		{DropOldest, subscriberBuffer, 101, sent, 100},
+		// Half the buffer fills normally, then 1 in SampleEvery of the
+		// remaining 612 entries is kept.
+		{Sample, subscriberBuffer/2 + 62, 1, sent - 1, sent - subscriberBuffer/2 - 62},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			log.SetOutput(new(bytes.Buffer))
+			defer log.SetOutput(os.Stderr)
+			sub := newSubscription(nil, "test", tt.policy)
+			var dropped int64
+			for seq := uint64(1); seq <= sent; seq++ {
+				if sub.deliver(model.LogEntry{Seq: seq}) {
+					dropped++
+				}
+			}
+
+			stats := sub.Stats()
+			if stats.Queued != tt.queued || stats.Dropped != tt.dropped || dropped != tt.dropped {
+				t.Errorf("queued %d, dropped %d (reported %d); want %d, %d", stats.Queued, stats.Dropped, dropped, tt.queued, tt.dropped)
+			}
+			if stats.Delivered != int64(tt.queued) || stats.Policy != tt.policy.String() {
+				t.Errorf("unexpected stats %+v", stats)
+			}
+			first := <-sub.ch
+			for len(sub.ch) > 1 {
+				<-sub.ch
+			}
+			last := <-sub.ch
+			if first.Seq != tt.first || last.Seq != tt.last {
+				t.Errorf("queue holds %d..%d, want %d..%d", first.Seq, last.Seq, tt.first, tt.last)
+			}
+		})
+	}
+}
+
+func TestDropWarningsAreRateLimited(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	sub := newSubscription(nil, "slow", DropNewest)
+	for i := 0; i < subscriberBuffer+500; i++ {
+		sub.deliver(model.LogEntry{})
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 1 {
+		t.Errorf("expected one warning for 500 drops, got %d:\n%s", n, buf.String())
+	}
+	if !strings.Contains(buf.String(), `"slow"`) {
+		t.Errorf("warning does not name the subscriber: %s", buf.String())
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	for _, p := range []Policy{DropNewest, DropOldest, Block, Sample} {
+		got, err := ParsePolicy(strings.ToUpper(p.String()))
+		if err != nil || got != p {
+			t.Errorf("ParsePolicy(%q) = %v, %v", p, got, err)
+		}
+	}
+	if _, err := ParsePolicy("drop"); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
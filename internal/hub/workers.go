@@ -0,0 +1,145 @@
+package hub
+
+import (
+	"context"
+
+	"github.com/atikulmunna/loom/internal/model"
+)
+
+// parseBatchSize is the most lines parsed as one unit of work. Lines are
+// batched only when they are already waiting, so a quiet stream is never
+// held back.
+const parseBatchSize = 64
+
+// parseBatch is a run of input lines and, once done is closed, their entries.
+type parseBatch struct {
+	lines   []model.RawLine
+	entries []model.LogEntry
+	done    chan struct{}
+}
+
+// parseLines parses the input on Options.Workers goroutines and returns the
+// entries in input order, a batch at a time. With more than one worker a
+// dispatcher hands batches to the workers round the pool and queues them in
+// arrival order, and a collector waits for each in turn, so a slow line
+// holds back the lines after it rather than being overtaken. The channel is
+// closed when the input ends or ctx is cancelled.
+func (h *Hub) parseLines(ctx context.Context) <-chan []model.LogEntry {
+	workers := h.opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	out := make(chan []model.LogEntry, workers)
+
+	if workers == 1 {
+		go func() {
+			defer close(out)
+			for {
+				lines, more := h.readBatch(ctx)
+				if len(lines) > 0 {
+					select {
+					case out <- h.parseAll(lines):
+					case <-ctx.Done():
+						return
+					}
+				}
+				if !more {
+					return
+				}
+			}
+		}()
+		return out
+	}
+
+	jobs := make(chan *parseBatch, workers)
+	ordered := make(chan *parseBatch, 2*workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for b := range jobs {
+				b.entries = h.parseAll(b.lines)
+				close(b.done)
+			}
+		}()
+	}
+
+	// Dispatcher. A batch is queued for the collector before a worker can
+	// finish it, and the workers always drain jobs, so neither send blocks
+	// for long.
+	go func() {
+		defer close(jobs)
+		defer close(ordered)
+		for {
+			lines, more := h.readBatch(ctx)
+			if len(lines) > 0 {
+				b := &parseBatch{lines: lines, done: make(chan struct{})}
+				select {
+				case ordered <- b:
+				case <-ctx.Done():
+					return
+				}
+				jobs <- b
+			}
+			if !more {
+				return
+			}
+		}
+	}()
+
+	// Collector.
+	go func() {
+		defer close(out)
+		for b := range ordered {
+			select {
+			case <-b.done:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case out <- b.entries:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// readBatch waits for a line, then takes up to parseBatchSize-1 more that
+// are already waiting. It reports false once the input has ended or ctx is
+// cancelled; lines read before that are still returned.
+func (h *Hub) readBatch(ctx context.Context) ([]model.RawLine, bool) {
+	var first model.RawLine
+	select {
+	case <-ctx.Done():
+		return nil, false
+	case raw, ok := <-h.input:
+		if !ok {
+			return nil, false
+		}
+		first = raw
+	}
+
+	lines := []model.RawLine{first}
+	for len(lines) < parseBatchSize {
+		select {
+		case raw, ok := <-h.input:
+			if !ok {
+				return lines, false
+			}
+			lines = append(lines, raw)
+		default:
+			return lines, true
+		}
+	}
+	return lines, true
+}
+
+// parseAll parses a batch of lines.
+func (h *Hub) parseAll(lines []model.RawLine) []model.LogEntry {
+	entries := make([]model.LogEntry, len(lines))
+	for i, raw := range lines {
+		entries[i] = h.parse(raw)
+	}
+	return entries
+}
@@ -0,0 +1,101 @@
+package hub
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/model"
+)
+
+// jitterParser takes longer on some lines so that workers finish out of order.
+type jitterParser struct{}
+
+func (jitterParser) Parse(raw, source string) model.LogEntry {
+	n, _ := strconv.Atoi(strings.TrimPrefix(raw, "line "))
+	time.Sleep(time.Duration(n%7) * 20 * time.Microsecond)
+	return model.LogEntry{Raw: raw, Message: raw, Source: source, Level: "INFO"}
+}
+
+func TestParseWorkersPreserveOrder(t *testing.T) {
+	const lines = 500
+	for _, workers := range []int{0, 1, 4} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			input := make(chan model.RawLine, 64)
+			h := New(input, jitterParser{}, Options{Workers: workers})
+			sub := h.Subscribe("test", Block)
+
+			go h.Start(context.Background())
+			go func() {
+				for i := 0; i < lines; i++ {
+					input <- model.RawLine{Text: fmt.Sprintf("line %d", i), Source: fmt.Sprintf("app%d.log", i%3)}
+				}
+				close(input)
+			}()
+
+			i := 0
+			for e := range sub.Entries() {
+				if want := fmt.Sprintf("line %d", i); e.Raw != want || e.Seq != uint64(i+1) {
+					t.Fatalf("entry %d: got %q (seq %d), want %q (seq %d)", i, e.Raw, e.Seq, want, i+1)
+				}
+				i++
+			}
+			if i != lines {
+				t.Errorf("got %d entries, want %d", i, lines)
+			}
+		})
+	}
+}
+
+func TestParseWorkersMerge(t *testing.T) {
+	base := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	input := make(chan model.RawLine, 10)
+	h := New(input, parserFunc(func(raw, source string) model.LogEntry {
+		sec, _ := strconv.Atoi(raw)
+		return model.LogEntry{Raw: raw, Timestamp: base.Add(time.Duration(sec) * time.Second), TimeParsed: true}
+	}), Options{Workers: 3, Merge: true, ReorderWindow: time.Minute})
+	sub := h.Subscribe("test", Block)
+
+	go h.Start(context.Background())
+	for _, sec := range []string{"3", "1", "2", "0"} {
+		input <- model.RawLine{Text: sec, Source: "app" + sec + ".log"}
+	}
+	close(input)
+
+	var got []string
+	for e := range sub.Entries() {
+		got = append(got, e.Raw)
+	}
+	if strings.Join(got, ",") != "0,1,2,3" {
+		t.Errorf("expected entries in timestamp order, got %v", got)
+	}
+}
+
+func TestParseWorkersStop(t *testing.T) {
+	input := make(chan model.RawLine)
+	h := New(input, jitterParser{}, Options{Workers: 4})
+	sub := h.Subscribe("test", DropNewest)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		h.Start(ctx)
+		close(done)
+	}()
+	input <- model.RawLine{Text: "line 1"}
+	<-sub.Entries()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Start did not return after cancel")
+	}
+}
+
+type parserFunc func(raw, source string) model.LogEntry
+
+func (f parserFunc) Parse(raw, source string) model.LogEntry { return f(raw, source) }
@@ -5,11 +5,14 @@ import "time"
 // LogEntry represents a single parsed log line.
 type LogEntry struct {
 	Timestamp time.Time         `json:"timestamp"`
+	TimeParsed bool             `json:"time_parsed"` // false when Timestamp is the ingest time
 	Source    string            `json:"source"`  // originating file path
 	Raw       string            `json:"raw"`     // original line text
 	Level    string            `json:"level"`   // INFO, WARN, ERROR, FATAL
 	Message  string            `json:"message"` // parsed message content
-	Fields   map[string]string `json:"fields,omitempty"` // extra parsed data (e.g., HTTP method, status)
+	Fields   map[string]Value  `json:"fields,omitempty"` // extra parsed data (e.g., HTTP method, status)
+	Late     bool              `json:"late,omitempty"`   // arrived after newer entries were emitted by the merge stage
+	Seq      uint64            `json:"seq,omitempty"`    // broadcast order, assigned by the hub from 1
 }
 
 // RawLine represents an unparsed line from a log file.
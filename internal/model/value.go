@@ -0,0 +1,252 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Kind is the type of a field Value.
+type Kind uint8
+
+const (
+	KindNull Kind = iota
+	KindString
+	KindInt
+	KindFloat
+	KindBool
+	KindTime
+	KindObject
+	KindArray
+)
+
+var kindNames = [...]string{"null", "string", "int", "float", "bool", "time", "object", "array"}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "unknown"
+}
+
+// Value is a typed field value. Numbers, booleans, times and nested objects
+// keep their type from the parser to the JSON output, so they can be compared
+// and aggregated; String renders any of them as text.
+//
+// The zero Value is null, which renders as "".
+type Value struct {
+	kind Kind
+	s    string    // string, or the original text of a time
+	i    int64     // int
+	f    float64   // float
+	b    bool      // bool
+	t    time.Time // time
+	obj  map[string]Value
+	arr  []Value
+}
+
+// StringValue returns a string Value.
+func StringValue(s string) Value { return Value{kind: KindString, s: s} }
+
+// IntValue returns an integer Value.
+func IntValue(i int64) Value { return Value{kind: KindInt, i: i} }
+
+// FloatValue returns a floating-point Value.
+func FloatValue(f float64) Value { return Value{kind: KindFloat, f: f} }
+
+// BoolValue returns a boolean Value.
+func BoolValue(b bool) Value { return Value{kind: KindBool, b: b} }
+
+// TimeValue returns a time Value, rendered in RFC 3339.
+func TimeValue(t time.Time) Value { return Value{kind: KindTime, t: t} }
+
+// ObjectValue returns a nested object Value.
+func ObjectValue(m map[string]Value) Value { return Value{kind: KindObject, obj: m} }
+
+// ArrayValue returns an array Value.
+func ArrayValue(a []Value) Value { return Value{kind: KindArray, arr: a} }
+
+// ValueOf converts a decoded JSON value (string, float64, json.Number, bool,
+// nil, map[string]interface{} or []interface{}) into a Value. Whole numbers
+// become ints, and strings in RFC 3339 form become times that keep their
+// original text.
+func ValueOf(v interface{}) Value {
+	switch v := v.(type) {
+	case nil:
+		return Value{}
+	case string:
+		if t, ok := rfc3339(v); ok {
+			return Value{kind: KindTime, s: v, t: t}
+		}
+		return StringValue(v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return IntValue(i)
+		}
+		f, _ := v.Float64()
+		return FloatValue(f)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return IntValue(int64(v))
+		}
+		return FloatValue(v)
+	case int:
+		return IntValue(int64(v))
+	case int64:
+		return IntValue(v)
+	case bool:
+		return BoolValue(v)
+	case time.Time:
+		return TimeValue(v)
+	case map[string]interface{}:
+		m := make(map[string]Value, len(v))
+		for k, e := range v {
+			m[k] = ValueOf(e)
+		}
+		return ObjectValue(m)
+	case []interface{}:
+		a := make([]Value, len(v))
+		for i, e := range v {
+			a[i] = ValueOf(e)
+		}
+		return ArrayValue(a)
+	case Value:
+		return v
+	default:
+		return StringValue(jsonText(v))
+	}
+}
+
+// rfc3339 parses s when it looks like an RFC 3339 timestamp.
+func rfc3339(s string) (time.Time, bool) {
+	if len(s) < len("2006-01-02T15:04:05Z") || s[4] != '-' || s[10] != 'T' {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	return t, err == nil
+}
+
+// Kind returns the type of the value.
+func (v Value) Kind() Kind { return v.kind }
+
+// String renders the value as text: numbers without exponents, times in
+// their original form (or RFC 3339), and objects and arrays as compact JSON.
+// Null renders as "".
+func (v Value) String() string {
+	switch v.kind {
+	case KindString:
+		return v.s
+	case KindInt:
+		return strconv.FormatInt(v.i, 10)
+	case KindFloat:
+		return strconv.FormatFloat(v.f, 'f', -1, 64)
+	case KindBool:
+		return strconv.FormatBool(v.b)
+	case KindTime:
+		if v.s != "" {
+			return v.s
+		}
+		return v.t.Format(time.RFC3339Nano)
+	case KindObject, KindArray:
+		return jsonText(v)
+	default:
+		return ""
+	}
+}
+
+// Int returns the value as an integer. Floats with no fractional part
+// convert; other kinds report false.
+func (v Value) Int() (int64, bool) {
+	switch v.kind {
+	case KindInt:
+		return v.i, true
+	case KindFloat:
+		if v.f == math.Trunc(v.f) && math.Abs(v.f) < 1<<63 {
+			return int64(v.f), true
+		}
+	}
+	return 0, false
+}
+
+// Float returns the value as a float. Ints convert; other kinds report false.
+func (v Value) Float() (float64, bool) {
+	switch v.kind {
+	case KindFloat:
+		return v.f, true
+	case KindInt:
+		return float64(v.i), true
+	}
+	return 0, false
+}
+
+// Bool returns a boolean value.
+func (v Value) Bool() (bool, bool) {
+	return v.b, v.kind == KindBool
+}
+
+// Time returns a time value.
+func (v Value) Time() (time.Time, bool) {
+	return v.t, v.kind == KindTime
+}
+
+// Object returns the fields of a nested object, or nil.
+func (v Value) Object() map[string]Value {
+	return v.obj
+}
+
+// Array returns the elements of an array, or nil.
+func (v Value) Array() []Value {
+	return v.arr
+}
+
+// MarshalJSON encodes the value as its natural JSON type. Times are strings.
+func (v Value) MarshalJSON() ([]byte, error) {
+	switch v.kind {
+	case KindString, KindTime:
+		return json.Marshal(v.String())
+	case KindInt:
+		return strconv.AppendInt(nil, v.i, 10), nil
+	case KindFloat:
+		if math.IsNaN(v.f) || math.IsInf(v.f, 0) {
+			return json.Marshal(v.String()) // not representable as a JSON number
+		}
+		return json.Marshal(v.f)
+	case KindBool:
+		return strconv.AppendBool(nil, v.b), nil
+	case KindObject:
+		if v.obj == nil {
+			return []byte("{}"), nil
+		}
+		return json.Marshal(v.obj)
+	case KindArray:
+		if v.arr == nil {
+			return []byte("[]"), nil
+		}
+		return json.Marshal(v.arr)
+	default:
+		return []byte("null"), nil
+	}
+}
+
+// UnmarshalJSON decodes any JSON value, with the same typing as ValueOf.
+func (v *Value) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	*v = ValueOf(raw)
+	return nil
+}
+
+// jsonText returns x as compact JSON, or "" when it cannot be encoded.
+func jsonText(x interface{}) string {
+	b, err := json.Marshal(x)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestValueString(t *testing.T) {
+	tests := []struct {
+		v    Value
+		want string
+	}{
+		{Value{}, ""},
+		{StringValue("GET"), "GET"},
+		{IntValue(1200000), "1200000"},
+		{FloatValue(1.2e6), "1200000"},
+		{FloatValue(0.25), "0.25"},
+		{BoolValue(true), "true"},
+		{TimeValue(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)), "2026-10-16T09:00:00Z"},
+		{ObjectValue(map[string]Value{"a": StringValue("b")}), `{"a":"b"}`},
+		{ArrayValue([]Value{IntValue(1), StringValue("x")}), `[1,"x"]`},
+	}
+	for _, tt := range tests {
+		if got := tt.v.String(); got != tt.want {
+			t.Errorf("%s value: expected %q, got %q", tt.v.Kind(), tt.want, got)
+		}
+	}
+}
+
+func TestValueJSONRoundTrip(t *testing.T) {
+	in := `{"big":9007199254740993,"bytes":1200000,"ratio":0.5,"ok":false,"none":null,` +
+		`"at":"2026-10-16T09:00:00.000Z","user":{"id":7,"tags":["a","b"]}}`
+
+	var fields map[string]Value
+	if err := json.Unmarshal([]byte(in), &fields); err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := map[string]Kind{
+		"big": KindInt, "bytes": KindInt, "ratio": KindFloat, "ok": KindBool,
+		"none": KindNull, "at": KindTime, "user": KindObject,
+	}
+	for k, want := range kinds {
+		if got := fields[k].Kind(); got != want {
+			t.Errorf("%s: expected kind %s, got %s", k, want, got)
+		}
+	}
+	if n, _ := fields["big"].Int(); n != 9007199254740993 {
+		t.Errorf("expected large int to stay exact, got %d", n)
+	}
+	if id, _ := fields["user"].Object()["id"].Int(); id != 7 {
+		t.Errorf("expected nested id 7, got %d", id)
+	}
+	if tags := fields["user"].Object()["tags"].Array(); len(tags) != 2 || tags[1].String() != "b" {
+		t.Errorf("unexpected nested array %v", tags)
+	}
+
+	out, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again map[string]Value
+	if err := json.Unmarshal(out, &again); err != nil {
+		t.Fatal(err)
+	}
+	for k := range fields {
+		if again[k].String() != fields[k].String() || again[k].Kind() != fields[k].Kind() {
+			t.Errorf("%s: round trip changed %s %q to %s %q", k, fields[k].Kind(), fields[k], again[k].Kind(), again[k])
+		}
+	}
+}
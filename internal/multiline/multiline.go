@@ -0,0 +1,252 @@
+package multiline
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/model"
+)
+
+const (
+	// DefaultMaxLines caps how many physical lines are folded into one event.
+	DefaultMaxLines = 500
+	// DefaultFlushTimeout is how long a pending event may wait for more lines.
+	DefaultFlushTimeout = 1 * time.Second
+
+	// minFlushTick bounds how often pending events are checked, however
+	// short the timeout.
+	minFlushTick = time.Millisecond
+)
+
+// Config describes how physical lines are grouped into logical events.
+//
+// A line continues the pending event when it matches any Continue pattern,
+// or when Start patterns are configured and the line matches none of them.
+// Everything else begins a new event.
+type Config struct {
+	Start        []*regexp.Regexp
+	Continue     []*regexp.Regexp
+	MaxLines     int
+	FlushTimeout time.Duration
+}
+
+// presets holds the built-in continuation rules for common runtimes.
+var presets = map[string][]string{
+	// Indented lines plus the two markers almost every stack trace uses.
+	"generic": {
+		`^\s+`,
+		`^Caused by:`,
+		`^Traceback`,
+	},
+	// java.lang.Exception: ... / \tat com.foo.Bar(Bar.java:12) / Caused by: ...
+	// The exception header joins the log line that reported it.
+	"java": {
+		`^[\w$.]+(Exception|Error|Throwable)(: .*)?$`,
+		`^\s+at `,
+		`^\s+\.\.\. \d+ (more|common frames omitted)`,
+		`^Caused by:`,
+		`^\s+Suppressed:`,
+	},
+	// Traceback (most recent call last): / File "x.py", line 3 / ValueError: bad
+	"python": {
+		`^Traceback \(most recent call last\):`,
+		`^\s+`,
+		`^During handling of the above exception`,
+		`^The above exception was the direct cause`,
+		`^$`,
+		`^[A-Za-z_][\w.]*(Error|Exception|Warning|Interrupt|Exit)\b`,
+	},
+	// panic: ... / goroutine 1 [running]: / main.main() / \t/src/main.go:12 +0x1d
+	"go": {
+		`^\s+`,
+		`^$`,
+		`^goroutine \d+ \[.*\]:$`,
+		`^[\w./*()\[\]-]+\(.*\)$`,
+		`^created by `,
+		`^exit status \d+$`,
+		`^\[signal `,
+	},
+	// Error: boom / \s+at fn (file.js:1:2)
+	"node": {
+		`^\s+at `,
+		`^\s+`,
+		`^\s*\^+$`,
+	},
+}
+
+// Presets returns the names of the built-in presets.
+func Presets() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewConfig builds a Config from an optional preset name plus extra
+// user-supplied start and continuation patterns.
+func NewConfig(preset string, start, cont []string, maxLines int, flushTimeout time.Duration) (Config, error) {
+	cfg := Config{MaxLines: maxLines, FlushTimeout: flushTimeout}
+
+	if preset != "" {
+		pats, ok := presets[strings.ToLower(preset)]
+		if !ok {
+			return cfg, fmt.Errorf("unknown multiline preset %q (available: %s)", preset, strings.Join(Presets(), ", "))
+		}
+		cont = append(append([]string{}, pats...), cont...)
+	}
+
+	var err error
+	if cfg.Start, err = compileAll(start); err != nil {
+		return cfg, err
+	}
+	if cfg.Continue, err = compileAll(cont); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+func compileAll(patterns []string) ([]*regexp.Regexp, error) {
+	var out []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid multiline pattern %q: %w", p, err)
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+// pending is an event being assembled for one source.
+type pending struct {
+	lines []string
+	last  time.Time
+}
+
+// Assembler folds continuation lines (stack traces, tracebacks, panics) into
+// the event that precedes them. Lines are grouped per source, so interleaved
+// writes to different files never get mixed together.
+type Assembler struct {
+	cfg     Config
+	input   <-chan model.RawLine
+	out     chan model.RawLine
+	pending map[string]*pending
+}
+
+// New creates an Assembler reading from input.
+func New(input <-chan model.RawLine, cfg Config) *Assembler {
+	if cfg.MaxLines <= 0 {
+		cfg.MaxLines = DefaultMaxLines
+	}
+	if cfg.FlushTimeout <= 0 {
+		cfg.FlushTimeout = DefaultFlushTimeout
+	}
+	return &Assembler{
+		cfg:     cfg,
+		input:   input,
+		out:     make(chan model.RawLine, 512),
+		pending: make(map[string]*pending),
+	}
+}
+
+// Lines returns the channel where assembled events are sent.
+// Multi-line events carry their physical lines joined with "\n".
+func (a *Assembler) Lines() <-chan model.RawLine {
+	return a.out
+}
+
+// Start assembles events until the context is cancelled or the input closes.
+// Pending events are flushed when the input closes; on cancellation they are
+// discarded along with everything else in flight.
+func (a *Assembler) Start(ctx context.Context) {
+	defer close(a.out)
+
+	tick := a.cfg.FlushTimeout / 2
+	if tick < minFlushTick {
+		tick = minFlushTick
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case raw, ok := <-a.input:
+			if !ok {
+				a.flushAll(ctx)
+				return
+			}
+			a.add(ctx, raw)
+		case now := <-ticker.C:
+			a.flushIdle(ctx, now)
+		}
+	}
+}
+
+// add appends a line to its source's pending event or starts a new one.
+func (a *Assembler) add(ctx context.Context, raw model.RawLine) {
+	p := a.pending[raw.Source]
+	if p != nil && a.continues(raw.Text) && len(p.lines) < a.cfg.MaxLines {
+		p.lines = append(p.lines, raw.Text)
+		p.last = time.Now()
+		return
+	}
+
+	a.flush(ctx, raw.Source)
+	a.pending[raw.Source] = &pending{lines: []string{raw.Text}, last: time.Now()}
+}
+
+// continues reports whether line belongs to the event before it.
+func (a *Assembler) continues(line string) bool {
+	for _, re := range a.cfg.Continue {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	if len(a.cfg.Start) == 0 {
+		return false
+	}
+	for _, re := range a.cfg.Start {
+		if re.MatchString(line) {
+			return false
+		}
+	}
+	return true
+}
+
+// flush emits the pending event for a source, if any. The event is
+// discarded if ctx is cancelled while the consumer is not reading.
+func (a *Assembler) flush(ctx context.Context, source string) {
+	p, ok := a.pending[source]
+	if !ok {
+		return
+	}
+	delete(a.pending, source)
+	select {
+	case a.out <- model.RawLine{Text: strings.Join(p.lines, "\n"), Source: source}:
+	case <-ctx.Done():
+	}
+}
+
+// flushIdle emits events that have not grown within the flush timeout.
+func (a *Assembler) flushIdle(ctx context.Context, now time.Time) {
+	for source, p := range a.pending {
+		if now.Sub(p.last) >= a.cfg.FlushTimeout {
+			a.flush(ctx, source)
+		}
+	}
+}
+
+// flushAll emits every pending event.
+func (a *Assembler) flushAll(ctx context.Context) {
+	for source := range a.pending {
+		a.flush(ctx, source)
+	}
+}
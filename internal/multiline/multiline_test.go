@@ -0,0 +1,167 @@
+package multiline
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/model"
+)
+
+// collect feeds lines through an Assembler and returns every emitted event.
+func collect(t *testing.T, cfg Config, lines []model.RawLine) []model.RawLine {
+	t.Helper()
+
+	input := make(chan model.RawLine, len(lines))
+	for _, l := range lines {
+		input <- l
+	}
+	close(input)
+
+	asm := New(input, cfg)
+	go asm.Start(context.Background())
+
+	var out []model.RawLine
+	for ev := range asm.Lines() {
+		out = append(out, ev)
+	}
+	return out
+}
+
+func TestJavaStackTrace(t *testing.T) {
+	cfg, err := NewConfig("java", nil, nil, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := collect(t, cfg, []model.RawLine{
+		{Text: "2026-02-17 ERROR request failed", Source: "app.log"},
+		{Text: "java.lang.IllegalStateException: boom", Source: "app.log"},
+		{Text: "\tat com.example.Foo.bar(Foo.java:12)", Source: "app.log"},
+		{Text: "Caused by: java.io.IOException: disk", Source: "app.log"},
+		{Text: "\t... 3 more", Source: "app.log"},
+		{Text: "2026-02-17 INFO recovered", Source: "app.log"},
+	})
+
+	if len(out) != 2 {
+		t.Fatalf("expected 2 events, got %d: %q", len(out), out)
+	}
+	if !strings.HasPrefix(out[0].Text, "2026-02-17 ERROR request failed\njava.lang.IllegalStateException: boom") || strings.Count(out[0].Text, "\n") != 4 {
+		t.Errorf("expected the log line and its trace as one event, got %q", out[0].Text)
+	}
+	if out[1].Text != "2026-02-17 INFO recovered" {
+		t.Errorf("expected trailing event, got %q", out[1].Text)
+	}
+}
+
+func TestStartPattern(t *testing.T) {
+	cfg, err := NewConfig("", []string{`^\d{4}-\d{2}-\d{2}`}, nil, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := collect(t, cfg, []model.RawLine{
+		{Text: "2026-02-17 ERROR panic", Source: "a.log"},
+		{Text: "goroutine 1 [running]:", Source: "a.log"},
+		{Text: "main.main()", Source: "a.log"},
+		{Text: "2026-02-17 INFO next", Source: "a.log"},
+	})
+
+	if len(out) != 2 {
+		t.Fatalf("expected 2 events, got %d: %q", len(out), out)
+	}
+	if out[0].Text != "2026-02-17 ERROR panic\ngoroutine 1 [running]:\nmain.main()" {
+		t.Errorf("unexpected first event: %q", out[0].Text)
+	}
+}
+
+func TestSourcesAreIndependent(t *testing.T) {
+	cfg, _ := NewConfig("generic", nil, nil, 0, 0)
+
+	out := collect(t, cfg, []model.RawLine{
+		{Text: "ERROR a failed", Source: "a.log"},
+		{Text: "INFO b ok", Source: "b.log"},
+		{Text: "  at a.go:1", Source: "a.log"},
+	})
+
+	for _, ev := range out {
+		if ev.Source == "a.log" && ev.Text != "ERROR a failed\n  at a.go:1" {
+			t.Errorf("continuation joined to wrong event: %q", ev.Text)
+		}
+	}
+}
+
+func TestMaxLines(t *testing.T) {
+	cfg, _ := NewConfig("generic", nil, nil, 2, 0)
+
+	out := collect(t, cfg, []model.RawLine{
+		{Text: "ERROR x", Source: "a.log"},
+		{Text: "  1", Source: "a.log"},
+		{Text: "  2", Source: "a.log"},
+	})
+
+	if len(out) != 2 {
+		t.Fatalf("expected cap to split into 2 events, got %d: %q", len(out), out)
+	}
+}
+
+func TestFlushTimeout(t *testing.T) {
+	// A timeout too short to halve into a tick must not panic.
+	for _, timeout := range []time.Duration{100 * time.Millisecond, time.Nanosecond} {
+		cfg, _ := NewConfig("generic", nil, nil, 0, timeout)
+
+		input := make(chan model.RawLine, 1)
+		asm := New(input, cfg)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go asm.Start(ctx)
+
+		input <- model.RawLine{Text: "ERROR trailing", Source: "a.log"}
+
+		select {
+		case ev := <-asm.Lines():
+			if ev.Text != "ERROR trailing" {
+				t.Errorf("%v: unexpected event: %q", timeout, ev.Text)
+			}
+		case <-time.After(1 * time.Second):
+			t.Fatalf("%v: pending event was never flushed", timeout)
+		}
+	}
+}
+
+func TestUnknownPreset(t *testing.T) {
+	if _, err := NewConfig("cobol", nil, nil, 0, 0); err == nil {
+		t.Error("expected error for unknown preset")
+	}
+}
+
+func TestStopWhileConsumerIsGone(t *testing.T) {
+	cfg, err := NewConfig("generic", nil, nil, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nobody reads Lines, so the output buffer fills and flush blocks.
+	input := make(chan model.RawLine, 1000)
+	for i := 0; i < 1000; i++ {
+		input <- model.RawLine{Text: "INFO line", Source: "app.log"}
+	}
+	asm := New(input, cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		asm.Start(ctx)
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Start did not return after cancel with a blocked consumer")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/atikulmunna/loom/internal/model"
@@ -47,6 +48,15 @@ func (r *TextRenderer) Render(entry model.LogEntry) error {
 	ts := entry.Timestamp.Format("15:04:05")
 
 	line := fmt.Sprintf("%s %s %s %s", ts, tag, src, entry.Message)
+	if entry.Late {
+		line += styleDebug.Render(" (late)")
+	}
+
+	// Multi-line events (stack traces) print their remaining lines below.
+	if _, rest, ok := strings.Cut(entry.Raw, "\n"); ok {
+		line += "\n" + rest
+	}
+
 	_, err := fmt.Fprintln(r.w, line)
 	return err
 }
@@ -20,6 +20,7 @@ func TestJSONRenderer(t *testing.T) {
 		Raw:       "2026-02-17 ERROR something broke",
 		Level:    "ERROR",
 		Message:  "something broke",
+		Fields:   map[string]model.Value{"status": model.IntValue(500)},
 	}
 
 	if err := renderer.Render(entry); err != nil {
@@ -41,4 +42,7 @@ func TestJSONRenderer(t *testing.T) {
 	if got.Source != "/var/log/app.log" {
 		t.Errorf("expected source '/var/log/app.log', got %q", got.Source)
 	}
+	if n, ok := got.Fields["status"].Int(); !ok || n != 500 {
+		t.Errorf("expected numeric status 500, got %q", got.Fields["status"])
+	}
 }
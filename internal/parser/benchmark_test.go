@@ -29,6 +29,18 @@ func BenchmarkCLFParser(b *testing.B) {
 	}
 }
 
+// BenchmarkLogfmtParser measures logfmt parsing throughput.
+func BenchmarkLogfmtParser(b *testing.B) {
+	p := NewLogfmtParser()
+	line := `level=error ts=2026-02-17T12:00:00Z msg="disk full" service=api request_id=abc-123`
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p.Parse(line, "bench.log")
+	}
+}
+
 // BenchmarkRegexParser measures custom regex parsing throughput.
 func BenchmarkRegexParser(b *testing.B) {
 	p, _ := NewRegexParser(`^(?P<timestamp>\S+) (?P<level>\w+) (?P<message>.+)$`)
@@ -0,0 +1,161 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Parser configuration
+// ---------------------------------------------------------------------------
+
+// Config describes a parser: its format plus optional per-source overrides.
+// The mapstructure tags match the keys used under parser.sources in ~/.loom.yaml.
+type Config struct {
+	Format  string `mapstructure:"format"`  // auto, json, clf, nginx, logfmt, syslog, rfc3164, rfc5424, regex
+	Pattern string `mapstructure:"pattern"` // regex (grok references allowed) or nginx log_format
+
+	// PatternFiles are grok pattern files, or directories of them, loaded
+	// on top of the built-in library.
+	PatternFiles []string `mapstructure:"pattern_files"`
+
+	// JSON mapping: a preset for a known logger (ecs, gcp, bunyan, pino, zap,
+	// logrus), then key overrides, e.g. level_field: log.level. Nested keys
+	// are given as dotted paths.
+	JSONPreset     string `mapstructure:"json_preset"`
+	LevelField     string `mapstructure:"level_field"`
+	MessageField   string `mapstructure:"message_field"`
+	TimestampField string `mapstructure:"timestamp_field"`
+
+	// TimestampLayout and TimestampLayouts are Go reference-time layouts tried
+	// before the format's default and the built-in detection; Timezone (IANA
+	// name) applies to stamps without an offset.
+	TimestampLayout  string   `mapstructure:"timestamp_layout"`
+	TimestampLayouts []string `mapstructure:"timestamp_layouts"`
+	Timezone         string   `mapstructure:"timezone"`
+}
+
+// New builds the parser described by cfg.
+func New(cfg Config) (Parser, error) {
+	layouts := cfg.TimestampLayouts
+	if cfg.TimestampLayout != "" {
+		layouts = append([]string{cfg.TimestampLayout}, layouts...)
+	}
+	tf, err := newTimeFormat(layouts, cfg.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(cfg.Format) {
+	case "json":
+		p := NewJSONParser()
+		p.ts = tf
+		if err := p.setPreset(cfg.JSONPreset); err != nil {
+			return nil, err
+		}
+		p.setKeys(cfg.LevelField, cfg.MessageField, cfg.TimestampField)
+		return p, nil
+	case "clf":
+		p := NewCLFParser()
+		p.ts = tf
+		return p, nil
+	case "nginx":
+		pattern := cfg.Pattern
+		if pattern == "" {
+			pattern = NginxCombined
+		}
+		p, err := NewNginxParser(pattern)
+		if err != nil {
+			return nil, err
+		}
+		p.ts = tf
+		return p, nil
+	case "logfmt":
+		p := NewLogfmtParser()
+		p.ts = tf
+		return p, nil
+	case "syslog", "rfc3164", "rfc5424":
+		variant := SyslogAuto
+		switch strings.ToLower(cfg.Format) {
+		case "rfc3164":
+			variant = SyslogRFC3164
+		case "rfc5424":
+			variant = SyslogRFC5424
+		}
+		p := NewSyslogParser(variant)
+		p.ts = tf
+		return p, nil
+	case "regex":
+		if cfg.Pattern == "" {
+			return nil, fmt.Errorf("a pattern is required for format regex")
+		}
+		lib, err := loadGrokPatterns(cfg.PatternFiles)
+		if err != nil {
+			return nil, err
+		}
+		p, err := newRegexParser(cfg.Pattern, lib)
+		if err != nil {
+			return nil, err
+		}
+		p.ts = tf
+		return p, nil
+	case "", "auto":
+		p := NewAutoParser()
+		p.jsonParser.ts = tf
+		if err := p.jsonParser.setPreset(cfg.JSONPreset); err != nil {
+			return nil, err
+		}
+		p.jsonParser.setKeys(cfg.LevelField, cfg.MessageField, cfg.TimestampField)
+		p.syslogParser.ts = tf
+		p.clfParser.ts = tf
+		p.logfmtParser.ts = tf
+		p.ts = tf
+		return p, nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+}
+
+// timeFormat holds the timestamp settings shared by every parser.
+// The zero value keeps each parser's built-in layout and zone handling.
+type timeFormat struct {
+	layouts []string       // tried before the parser's default layout
+	loc     *time.Location // zone for stamps without an offset; nil = parser default
+}
+
+func newTimeFormat(layouts []string, timezone string) (timeFormat, error) {
+	tf := timeFormat{layouts: layouts}
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return tf, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		}
+		tf.loc = loc
+	}
+	return tf, nil
+}
+
+// parse parses s with the configured layouts, then defaultLayout, then the
+// built-in detection of common layouts and epochs.
+func (f timeFormat) parse(s, defaultLayout string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	loc := f.location(time.UTC)
+	for _, layout := range f.layouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, true
+		}
+	}
+	if t, err := time.ParseInLocation(defaultLayout, s, loc); err == nil {
+		return t, true
+	}
+	return detectTime(s, loc)
+}
+
+// location returns the configured zone, or def when none is set.
+func (f timeFormat) location(def *time.Location) *time.Location {
+	if f.loc != nil {
+		return f.loc
+	}
+	return def
+}
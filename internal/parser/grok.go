@@ -0,0 +1,271 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/atikulmunna/loom/internal/model"
+)
+
+// ---------------------------------------------------------------------------
+// Grok patterns
+// ---------------------------------------------------------------------------
+
+// grokBuiltins is the standard Logstash pattern library, rewritten where
+// needed for RE2: lookarounds and atomic groups are dropped and capturing
+// groups made non-capturing. Extra pattern files use the same format.
+const grokBuiltins = `
+USERNAME [a-zA-Z0-9._-]+
+USER %{USERNAME}
+EMAILLOCALPART [a-zA-Z0-9!#$%&'*+/=?^_{|}~-]+(?:\.[a-zA-Z0-9!#$%&'*+/=?^_{|}~-]+)*
+EMAILADDRESS %{EMAILLOCALPART}@%{HOSTNAME}
+INT (?:[+-]?(?:[0-9]+))
+BASE10NUM [+-]?(?:[0-9]+(?:\.[0-9]+)?|\.[0-9]+)
+NUMBER (?:%{BASE10NUM})
+BASE16NUM [+-]?(?:0x)?(?:[0-9A-Fa-f]+)
+POSINT \b(?:[1-9][0-9]*)\b
+NONNEGINT \b(?:[0-9]+)\b
+WORD \b\w+\b
+NOTSPACE \S+
+SPACE \s*
+DATA .*?
+GREEDYDATA .*
+QUOTEDSTRING "(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'
+QS %{QUOTEDSTRING}
+UUID [A-Fa-f0-9]{8}-(?:[A-Fa-f0-9]{4}-){3}[A-Fa-f0-9]{12}
+
+# Networking
+CISCOMAC (?:[A-Fa-f0-9]{4}\.){2}[A-Fa-f0-9]{4}
+WINDOWSMAC (?:[A-Fa-f0-9]{2}-){5}[A-Fa-f0-9]{2}
+COMMONMAC (?:[A-Fa-f0-9]{2}:){5}[A-Fa-f0-9]{2}
+MAC (?:%{CISCOMAC}|%{WINDOWSMAC}|%{COMMONMAC})
+IPV6 (?:(?:[0-9A-Fa-f]{1,4}:){7}[0-9A-Fa-f]{1,4}|(?:[0-9A-Fa-f]{1,4}:){1,7}:|(?:[0-9A-Fa-f]{1,4}:){1,6}:[0-9A-Fa-f]{1,4}|(?:[0-9A-Fa-f]{1,4}:){1,5}(?::[0-9A-Fa-f]{1,4}){1,2}|(?:[0-9A-Fa-f]{1,4}:){1,4}(?::[0-9A-Fa-f]{1,4}){1,3}|(?:[0-9A-Fa-f]{1,4}:){1,3}(?::[0-9A-Fa-f]{1,4}){1,4}|(?:[0-9A-Fa-f]{1,4}:){1,2}(?::[0-9A-Fa-f]{1,4}){1,5}|[0-9A-Fa-f]{1,4}:(?::[0-9A-Fa-f]{1,4}){1,6}|:(?:(?::[0-9A-Fa-f]{1,4}){1,7}|:)|::(?:ffff(?::0{1,4})?:)?%{IPV4}|(?:[0-9A-Fa-f]{1,4}:){1,4}:%{IPV4})(?:%[0-9A-Za-z]+)?
+IPV4 (?:(?:25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9]?[0-9])\.){3}(?:25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9]?[0-9])
+IP (?:%{IPV6}|%{IPV4})
+HOSTNAME \b(?:[0-9A-Za-z][0-9A-Za-z-]{0,62})(?:\.(?:[0-9A-Za-z][0-9A-Za-z-]{0,62}))*\.?
+IPORHOST (?:%{IP}|%{HOSTNAME})
+HOSTPORT %{IPORHOST}:%{POSINT}
+
+# Paths and URIs
+PATH (?:%{UNIXPATH}|%{WINPATH})
+UNIXPATH (?:/[\w_%!$@:.,+~-]*)+
+TTY (?:/dev/(?:pts|tty(?:[pq])?)(?:\w+)?/?(?:[0-9]+))
+WINPATH (?:[A-Za-z]+:|\\)(?:\\[^\\?*]*)+
+URIPROTO [A-Za-z][A-Za-z0-9+\-.]+
+URIHOST %{IPORHOST}(?::%{POSINT})?
+URIPATH (?:/[A-Za-z0-9$.+!*'(){},~:;=@#%&_\-]*)+
+URIPARAM \?[A-Za-z0-9$.+!*'|(){},~@#%&/=:;_?\-\[\]<>]*
+URIPATHPARAM %{URIPATH}(?:%{URIPARAM})?
+URI %{URIPROTO}://(?:%{USER}(?::[^@]*)?@)?(?:%{URIHOST})?(?:%{URIPATHPARAM})?
+
+# Dates and times
+MONTH \b(?:[Jj]an(?:uary)?|[Ff]eb(?:ruary)?|[Mm]ar(?:ch)?|[Aa]pr(?:il)?|[Mm]ay|[Jj]un(?:e)?|[Jj]ul(?:y)?|[Aa]ug(?:ust)?|[Ss]ep(?:tember)?|[Oo]ct(?:ober)?|[Nn]ov(?:ember)?|[Dd]ec(?:ember)?)\b
+MONTHNUM (?:0?[1-9]|1[0-2])
+MONTHNUM2 (?:0[1-9]|1[0-2])
+MONTHDAY (?:(?:0[1-9])|(?:[12][0-9])|(?:3[01])|[1-9])
+DAY (?:Mon(?:day)?|Tue(?:sday)?|Wed(?:nesday)?|Thu(?:rsday)?|Fri(?:day)?|Sat(?:urday)?|Sun(?:day)?)
+YEAR (?:\d\d){1,2}
+HOUR (?:2[0123]|[01]?[0-9])
+MINUTE (?:[0-5][0-9])
+SECOND (?:(?:[0-5]?[0-9]|60)(?:[:.,][0-9]+)?)
+TIME %{HOUR}:%{MINUTE}(?::%{SECOND})
+DATE_US %{MONTHNUM}[/-]%{MONTHDAY}[/-]%{YEAR}
+DATE_EU %{MONTHDAY}[./-]%{MONTHNUM}[./-]%{YEAR}
+ISO8601_TIMEZONE (?:Z|[+-]%{HOUR}(?::?%{MINUTE}))
+ISO8601_SECOND %{SECOND}
+TIMESTAMP_ISO8601 %{YEAR}-%{MONTHNUM}-%{MONTHDAY}[T ]%{HOUR}:?%{MINUTE}(?::?%{SECOND})?%{ISO8601_TIMEZONE}?
+DATE %{DATE_US}|%{DATE_EU}
+DATESTAMP %{DATE}[- ]%{TIME}
+TZ (?:[APMCE][SD]T|UTC)
+DATESTAMP_RFC822 %{DAY} %{MONTH} %{MONTHDAY} %{YEAR} %{TIME} %{TZ}
+DATESTAMP_RFC2822 %{DAY}, %{MONTHDAY} %{MONTH} %{YEAR} %{TIME} %{ISO8601_TIMEZONE}
+DATESTAMP_OTHER %{DAY} %{MONTH} %{MONTHDAY} %{TIME} %{TZ} %{YEAR}
+DATESTAMP_EVENTLOG %{YEAR}%{MONTHNUM2}%{MONTHDAY}%{HOUR}%{MINUTE}%{SECOND}
+HTTPDATE %{MONTHDAY}/%{MONTH}/%{YEAR}:%{TIME} %{INT}
+
+# Syslog
+SYSLOGTIMESTAMP %{MONTH} +%{MONTHDAY} %{TIME}
+PROG [\x21-\x5a\x5c\x5e-\x7e]+
+SYSLOGPROG %{PROG:program}(?:\[%{POSINT:pid}\])?
+SYSLOGHOST %{IPORHOST}
+SYSLOGFACILITY <%{NONNEGINT:facility}.%{NONNEGINT:priority}>
+SYSLOGBASE %{SYSLOGTIMESTAMP:timestamp} (?:%{SYSLOGFACILITY} )?%{SYSLOGHOST:logsource} %{SYSLOGPROG}:
+
+# Web servers
+HTTPDUSER %{EMAILADDRESS}|%{USER}
+COMMONAPACHELOG %{IPORHOST:clientip} %{HTTPDUSER:ident} %{USER:auth} \[%{HTTPDATE:timestamp}\] "(?:%{WORD:verb} %{NOTSPACE:request}(?: HTTP/%{NUMBER:httpversion})?|%{DATA:rawrequest})" %{NUMBER:response:int} (?:%{NUMBER:bytes:int}|-)
+COMBINEDAPACHELOG %{COMMONAPACHELOG} %{QS:referrer} %{QS:agent}
+
+# Levels
+LOGLEVEL (?:[Aa]lert|ALERT|[Tt]race|TRACE|[Dd]ebug|DEBUG|[Nn]otice|NOTICE|[Ii]nfo|INFO|[Ww]arn?(?:ing)?|WARN?(?:ING)?|[Ee]rr?(?:or)?|ERR?(?:OR)?|[Cc]rit?(?:ical)?|CRIT?(?:ICAL)?|[Ff]atal|FATAL|[Ss]evere|SEVERE|EMERG(?:ENCY)?|[Ee]merg(?:ency)?)
+`
+
+// grokLibrary holds the built-in patterns by name.
+var grokLibrary = mustGrokPatterns(grokBuiltins)
+
+// grokRef matches %{NAME}, %{NAME:field} and %{NAME:field:type}.
+var grokRef = regexp.MustCompile(`%\{(\w+)(?::([\w.@\[\]-]+))?(?::(\w+))?\}`)
+
+// grokCapture is the field a generated capture group fills.
+type grokCapture struct {
+	field string
+	typ   string // "", int or float
+}
+
+// isGrok reports whether a pattern uses grok references.
+func isGrok(pattern string) bool {
+	return grokRef.MatchString(pattern)
+}
+
+// compileGrok expands the grok references in pattern with lib and returns
+// the regex plus the field for every capture group it generated. Capture
+// groups are numbered, since field names may contain dots.
+func compileGrok(pattern string, lib map[string]string) (string, map[string]grokCapture, error) {
+	captures := make(map[string]grokCapture)
+	var expand func(s string, depth int) (string, error)
+	expand = func(s string, depth int) (string, error) {
+		if depth > 32 {
+			return "", fmt.Errorf("grok patterns nest too deeply (is one defined in terms of itself?)")
+		}
+		var err error
+		out := grokRef.ReplaceAllStringFunc(s, func(ref string) string {
+			if err != nil {
+				return ""
+			}
+			m := grokRef.FindStringSubmatch(ref)
+			def, ok := lib[m[1]]
+			if !ok {
+				err = fmt.Errorf("unknown grok pattern %%{%s}", m[1])
+				return ""
+			}
+			inner, e := expand(def, depth+1)
+			if e != nil {
+				err = e
+				return ""
+			}
+			if m[2] == "" {
+				return "(?:" + inner + ")"
+			}
+			switch m[3] {
+			case "", "int", "float":
+			default:
+				err = fmt.Errorf("%s: unknown type %q (want int or float)", ref, m[3])
+				return ""
+			}
+			name := "_grok" + strconv.Itoa(len(captures))
+			captures[name] = grokCapture{field: grokField(m[2]), typ: m[3]}
+			return "(?P<" + name + ">" + inner + ")"
+		})
+		return out, err
+	}
+
+	re, err := expand(pattern, 0)
+	return re, captures, err
+}
+
+// grokField turns Logstash's nested field syntax ("[http][method]") into a
+// dotted path, like flattened JSON keys.
+func grokField(name string) string {
+	if !strings.HasPrefix(name, "[") {
+		return name
+	}
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "["), "]")
+	return strings.ReplaceAll(name, "][", ".")
+}
+
+// grokValue converts a captured value to the type requested in the pattern.
+// Values that do not convert stay strings.
+func grokValue(s, typ string) model.Value {
+	switch typ {
+	case "int":
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return model.IntValue(i)
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return model.IntValue(int64(f))
+		}
+	case "float":
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return model.FloatValue(f)
+		}
+	}
+	return model.StringValue(s)
+}
+
+// loadGrokPatterns returns the built-in library extended with the patterns
+// in files. A directory loads every file in it. Later definitions win.
+func loadGrokPatterns(files []string) (map[string]string, error) {
+	if len(files) == 0 {
+		return grokLibrary, nil
+	}
+
+	lib := make(map[string]string, len(grokLibrary))
+	for name, def := range grokLibrary {
+		lib[name] = def
+	}
+	for _, path := range files {
+		paths := []string{path}
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return nil, err
+			}
+			paths = paths[:0]
+			for _, e := range entries {
+				if !e.IsDir() {
+					paths = append(paths, filepath.Join(path, e.Name()))
+				}
+			}
+		}
+		for _, p := range paths {
+			f, err := os.Open(p)
+			if err != nil {
+				return nil, fmt.Errorf("grok patterns: %w", err)
+			}
+			err = readGrokPatterns(f, lib)
+			f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("grok patterns %s: %w", p, err)
+			}
+		}
+	}
+	return lib, nil
+}
+
+// readGrokPatterns reads "NAME regex" lines into lib. Blank lines and lines
+// starting with # are skipped.
+func readGrokPatterns(r io.Reader, lib map[string]string) error {
+	scanner := bufio.NewScanner(r)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		i := strings.IndexAny(line, " \t")
+		if i < 0 {
+			return fmt.Errorf("line %d: expected NAME followed by a pattern", n)
+		}
+		name, def := line[:i], strings.TrimSpace(line[i:])
+		if def == "" {
+			return fmt.Errorf("line %d: expected NAME followed by a pattern", n)
+		}
+		lib[name] = def
+	}
+	return scanner.Err()
+}
+
+func mustGrokPatterns(s string) map[string]string {
+	lib := make(map[string]string)
+	if err := readGrokPatterns(strings.NewReader(s), lib); err != nil {
+		panic(err)
+	}
+	return lib
+}
@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGrokPattern(t *testing.T) {
+	p, err := NewRegexParser(`%{IP:client} %{WORD:method} %{URIPATHPARAM:path} %{NUMBER:bytes:int} %{NUMBER:duration:float}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := p.Parse("55.3.244.1 GET /index.html?page=2 15824 0.043", "app.log")
+
+	want := map[string]string{
+		"client": "55.3.244.1", "method": "GET", "path": "/index.html?page=2",
+		"bytes": "15824", "duration": "0.043",
+	}
+	for k, v := range want {
+		if entry.Fields[k].String() != v {
+			t.Errorf("field %s: expected %q, got %q", k, v, entry.Fields[k])
+		}
+	}
+	if n, ok := entry.Fields["bytes"].Int(); !ok || n != 15824 {
+		t.Errorf("expected bytes typed as int, got %s", entry.Fields["bytes"].Kind())
+	}
+	if f, ok := entry.Fields["duration"].Float(); !ok || f != 0.043 {
+		t.Errorf("expected duration typed as float, got %s", entry.Fields["duration"].Kind())
+	}
+}
+
+func TestGrokLevelMessageTimestamp(t *testing.T) {
+	p, err := NewRegexParser(`^%{TIMESTAMP_ISO8601:timestamp} \[%{LOGLEVEL:level}\] %{GREEDYDATA:message}$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := p.Parse("2026-10-16T09:00:00Z [error] connection reset", "app.log")
+
+	if entry.Level != "ERROR" || entry.Message != "connection reset" {
+		t.Errorf("unexpected level/message: %s %q", entry.Level, entry.Message)
+	}
+	if !entry.Timestamp.Equal(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected timestamp %v", entry.Timestamp)
+	}
+}
+
+func TestGrokCombinedApacheLog(t *testing.T) {
+	p, err := NewRegexParser(`%{COMBINEDAPACHELOG}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line := `127.0.0.1 - frank [17/Feb/2026:12:00:00 +0000] "GET /api/health HTTP/1.1" 503 - "-" "curl/8.0"`
+	entry := p.Parse(line, "access.log")
+
+	if entry.Fields["clientip"].String() != "127.0.0.1" || entry.Fields["verb"].String() != "GET" {
+		t.Errorf("unexpected fields %v", entry.Fields)
+	}
+	if n, ok := entry.Fields["response"].Int(); !ok || n != 503 {
+		t.Errorf("expected int response 503, got %v", entry.Fields["response"])
+	}
+	if !entry.TimeParsed {
+		t.Error("expected the HTTPDATE timestamp to be parsed")
+	}
+}
+
+func TestGrokPatternFiles(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "custom")
+	os.WriteFile(file, []byte("# order service\nORDERID ORD-[0-9]{6}\nORDERLINE %{ORDERID:[order][id]} total=%{NUMBER:[order][total]:float}\n"), 0644)
+
+	p, err := New(Config{Format: "regex", Pattern: `%{ORDERLINE}`, PatternFiles: []string{dir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := p.Parse("ORD-004211 total=19.90", "orders.log")
+	if entry.Fields["order.id"].String() != "ORD-004211" {
+		t.Errorf("expected nested field name order.id, got %v", entry.Fields)
+	}
+	if f, ok := entry.Fields["order.total"].Float(); !ok || f != 19.9 {
+		t.Errorf("expected float order.total, got %v", entry.Fields["order.total"])
+	}
+}
+
+func TestGrokErrors(t *testing.T) {
+	for _, pattern := range []string{
+		`%{NOPE:x}`,
+		`%{NUMBER:x:bool}`,
+	} {
+		if _, err := NewRegexParser(pattern); err == nil {
+			t.Errorf("%s: expected an error", pattern)
+		}
+	}
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "loop")
+	os.WriteFile(file, []byte("LOOP a%{LOOP}\n"), 0644)
+	if _, err := New(Config{Format: "regex", Pattern: `%{LOOP}`, PatternFiles: []string{file}}); err == nil {
+		t.Error("expected an error for a self-referencing pattern")
+	}
+}
+
+func TestGrokBuiltinsCompile(t *testing.T) {
+	for name := range grokLibrary {
+		if _, err := NewRegexParser("%{" + name + "}"); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// JSON field mapping
+// ---------------------------------------------------------------------------
+
+// jsonPreset says which (dotted) paths of a JSON logger's output hold the
+// level, message and timestamp. The first path present wins.
+type jsonPreset struct {
+	level, message, timestamp []string
+	numericLevels             bool // Bunyan/pino: 10 trace … 60 fatal
+}
+
+// jsonPresets are the built-in mappings for common JSON loggers.
+var jsonPresets = map[string]jsonPreset{
+	// Elastic Common Schema.
+	"ecs": {
+		level:     []string{"log.level"},
+		message:   []string{"message", "error.message"},
+		timestamp: []string{"@timestamp"},
+	},
+	// Google Cloud Logging, as written by agents and as exported.
+	"gcp": {
+		level:     []string{"severity"},
+		message:   []string{"message", "textPayload", "jsonPayload.message"},
+		timestamp: []string{"timestamp", "time", "receiveTimestamp"},
+	},
+	"bunyan": {
+		level:         []string{"level"},
+		message:       []string{"msg"},
+		timestamp:     []string{"time"},
+		numericLevels: true,
+	},
+	"pino": {
+		level:         []string{"level"},
+		message:       []string{"msg"},
+		timestamp:     []string{"time"},
+		numericLevels: true,
+	},
+	"zap": {
+		level:     []string{"level"},
+		message:   []string{"msg"},
+		timestamp: []string{"ts"},
+	},
+	"logrus": {
+		level:     []string{"level"},
+		message:   []string{"msg"},
+		timestamp: []string{"time"},
+	},
+}
+
+// JSONPresets returns the names of the built-in JSON presets.
+func JSONPresets() []string {
+	names := make([]string, 0, len(jsonPresets))
+	for name := range jsonPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// setPreset switches the parser to a built-in mapping.
+func (p *JSONParser) setPreset(name string) error {
+	if name == "" {
+		return nil
+	}
+	preset, ok := jsonPresets[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("unknown JSON preset %q (available: %s)", name, strings.Join(JSONPresets(), ", "))
+	}
+	p.levelKeys = preset.level
+	p.messageKeys = preset.message
+	p.timeKeys = preset.timestamp
+	p.numericLevels = preset.numericLevels
+	return nil
+}
+
+// level normalizes a level value, including Bunyan/pino numbers when the
+// preset uses them.
+func (p *JSONParser) level(v string) string {
+	if p.numericLevels {
+		if n, err := strconv.Atoi(v); err == nil {
+			return numericLevel(n)
+		}
+	}
+	return normalizeLevel(v)
+}
+
+// numericLevel maps Bunyan/pino levels (10 trace, 20 debug, 30 info, 40 warn,
+// 50 error, 60 fatal) to Loom's levels.
+func numericLevel(n int) string {
+	switch {
+	case n >= 60:
+		return "FATAL"
+	case n >= 50:
+		return "ERROR"
+	case n >= 40:
+		return "WARN"
+	case n >= 30:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
+
+// flatten moves nested objects into dotted keys, so {"http":{"status":200}}
+// becomes {"http.status":200}. Arrays and empty objects are kept whole.
+func flatten(data map[string]interface{}) map[string]interface{} {
+	nested := false
+	for _, v := range data {
+		if m, ok := v.(map[string]interface{}); ok && len(m) > 0 {
+			nested = true
+			break
+		}
+	}
+	if !nested {
+		return data
+	}
+
+	flat := make(map[string]interface{}, len(data))
+	var walk func(prefix string, m map[string]interface{})
+	walk = func(prefix string, m map[string]interface{}) {
+		for k, v := range m {
+			if prefix != "" {
+				k = prefix + "." + k
+			}
+			if inner, ok := v.(map[string]interface{}); ok && len(inner) > 0 {
+				walk(k, inner)
+				continue
+			}
+			flat[k] = v
+		}
+	}
+	walk("", data)
+	return flat
+}
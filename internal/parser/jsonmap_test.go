@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJSONPresets(t *testing.T) {
+	want := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		preset, line, level, message string
+	}{
+		{"ecs", `{"@timestamp":"2026-10-16T09:00:00Z","log":{"level":"warn"},"message":"slow","http":{"response":{"status_code":504}}}`, "WARN", "slow"},
+		{"ecs", `{"@timestamp":"2026-10-16T09:00:00Z","log.level":"error","error":{"message":"boom"}}`, "ERROR", "boom"},
+		{"gcp", `{"severity":"CRITICAL","textPayload":"db down","timestamp":"2026-10-16T09:00:00Z"}`, "FATAL", "db down"},
+		{"bunyan", `{"level":50,"msg":"failed","time":"2026-10-16T09:00:00.000Z"}`, "ERROR", "failed"},
+		{"pino", `{"level":30,"msg":"listening","time":1792141200000}`, "INFO", "listening"},
+		{"zap", `{"level":"dpanic","ts":1792141200.0,"msg":"bad state","caller":"main.go:12"}`, "FATAL", "bad state"},
+		{"logrus", `{"level":"warning","msg":"retrying","time":"2026-10-16T09:00:00Z"}`, "WARN", "retrying"},
+	}
+
+	for _, tt := range tests {
+		p, err := New(Config{Format: "json", JSONPreset: tt.preset})
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry := p.Parse(tt.line, "app.log")
+		if entry.Level != tt.level || entry.Message != tt.message {
+			t.Errorf("%s: expected %s %q, got %s %q", tt.preset, tt.level, tt.message, entry.Level, entry.Message)
+		}
+		if !entry.TimeParsed || !entry.Timestamp.Equal(want) {
+			t.Errorf("%s: expected timestamp %v, got %v (parsed=%v)", tt.preset, want, entry.Timestamp, entry.TimeParsed)
+		}
+	}
+
+	if _, err := New(Config{Format: "json", JSONPreset: "log4j"}); err == nil {
+		t.Error("expected an error for an unknown preset")
+	}
+}
+
+func TestJSONFlattenAndFieldPaths(t *testing.T) {
+	p, err := New(Config{Format: "json", LevelField: "log.level", MessageField: "event.text"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := p.Parse(`{"log":{"level":"error","logger":"db"},"event":{"text":"lost connection"},"http":{"response":{"status_code":503}},"tags":["a","b"]}`, "app.log")
+
+	if entry.Level != "ERROR" || entry.Message != "lost connection" {
+		t.Errorf("expected mapped level and message, got %s %q", entry.Level, entry.Message)
+	}
+	if n, ok := entry.Fields["http.response.status_code"].Int(); !ok || n != 503 {
+		t.Errorf("expected flattened status code 503, got %v", entry.Fields)
+	}
+	if entry.Fields["log.logger"].String() != "db" {
+		t.Errorf("expected sibling of the level kept as log.logger, got %v", entry.Fields)
+	}
+	if _, ok := entry.Fields["log.level"]; ok {
+		t.Error("expected the consumed level path to be removed from fields")
+	}
+	if len(entry.Fields["tags"].Array()) != 2 {
+		t.Errorf("expected arrays kept whole, got %v", entry.Fields["tags"])
+	}
+}
@@ -0,0 +1,214 @@
+package parser
+
+import (
+	"strings"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/model"
+)
+
+// ---------------------------------------------------------------------------
+// Logfmt Parser
+// ---------------------------------------------------------------------------
+
+// LogfmtParser handles key=value lines as written by go-kit, logrus and slog:
+//
+//	level=info msg="request done" dur=12ms cached
+//
+// Recognizes level/lvl, msg/message and ts/time/timestamp; every other pair
+// is stored in Fields. Unquoted numbers, booleans and RFC 3339 times keep
+// their type; quoted values are always strings. Bare keys are recorded with
+// the value true, except a leading timestamp ("2026-10-16T09:00:00Z
+// level=error ..."), which becomes the entry's time.
+type LogfmtParser struct {
+	ts timeFormat
+}
+
+func NewLogfmtParser() *LogfmtParser { return &LogfmtParser{} }
+
+func (p *LogfmtParser) Parse(raw string, source string) model.LogEntry {
+	entry, _ := p.parse(raw, source)
+	return entry
+}
+
+// parse returns the entry and whether the line looked like logfmt at all:
+// it must tokenize cleanly and contain either a well-known key, or at least
+// two key=value pairs and no bare words. Without a well-known key, bare words
+// are prose ("ERROR failed to connect host=db port=5432"), not logfmt.
+func (p *LogfmtParser) parse(raw string, source string) (model.LogEntry, bool) {
+	entry := base(raw, source)
+
+	pairs, ok := splitLogfmt(raw)
+	if !ok {
+		return entry, false
+	}
+
+	if n, t, ok := p.leadingTime(pairs); ok {
+		setTime(&entry, t)
+		pairs = pairs[n:]
+	}
+
+	var assigned, bare int
+	var known bool
+	fields := make(map[string]model.Value)
+
+	for _, kv := range pairs {
+		if kv.bare {
+			fields[kv.key] = model.BoolValue(true)
+			bare++
+			continue
+		}
+		assigned++
+
+		switch kv.key {
+		case "level", "lvl":
+			entry.Level = normalizeLevel(kv.val)
+			known = true
+		case "msg", "message":
+			entry.Message = kv.val
+			known = true
+		case "ts", "time", "timestamp":
+			if t, ok := p.ts.parse(kv.val, time.RFC3339); ok {
+				setTime(&entry, t)
+			}
+			known = true
+		default:
+			fields[kv.key] = logfmtValue(kv)
+		}
+	}
+
+	if !known && (assigned < 2 || bare > 0) {
+		return base(raw, source), false
+	}
+
+	entry.Fields = fields
+	return entry, true
+}
+
+// leadingTime reads a timestamp from the bare words a line starts with,
+// optionally in brackets, and reports how many words it took.
+func (p *LogfmtParser) leadingTime(pairs []logfmtPair) (int, time.Time, bool) {
+	var words []string
+	for _, kv := range pairs {
+		if !kv.bare || !timeWord(kv.key) || len(words) == 6 {
+			break
+		}
+		words = append(words, kv.key)
+	}
+	for n := len(words); n > 0; n-- {
+		s := strings.Join(words[:n], " ")
+		if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+			s = s[1 : len(s)-1]
+		}
+		if t, ok := p.ts.parseText(s, n); ok {
+			return n, t, true
+		}
+	}
+	return 0, time.Time{}, false
+}
+
+// logfmtPair is one key[=value] token.
+type logfmtPair struct {
+	key    string
+	val    string
+	bare   bool
+	quoted bool
+}
+
+// logfmtValue types an unquoted value the way JSON values are typed:
+// integers, floats, true/false and RFC 3339 times.
+func logfmtValue(kv logfmtPair) model.Value {
+	if kv.quoted {
+		return model.StringValue(kv.val)
+	}
+	switch kv.val {
+	case "true":
+		return model.BoolValue(true)
+	case "false":
+		return model.BoolValue(false)
+	}
+	if v := numberValue(kv.val); v.Kind() != model.KindString {
+		return v
+	}
+	return model.ValueOf(kv.val)
+}
+
+// splitLogfmt tokenizes a logfmt line. It reports false for input that is
+// not logfmt: empty keys, unterminated quotes or stray characters.
+func splitLogfmt(line string) ([]logfmtPair, bool) {
+	var pairs []logfmtPair
+	i, n := 0, len(line)
+
+	for {
+		for i < n && (line[i] == ' ' || line[i] == '\t') {
+			i++
+		}
+		if i >= n {
+			break
+		}
+
+		// Key runs until '=', whitespace or a quote.
+		start := i
+		for i < n && line[i] > ' ' && line[i] != '=' && line[i] != '"' {
+			i++
+		}
+		if i == start {
+			return nil, false
+		}
+		key := line[start:i]
+
+		if i >= n || line[i] == ' ' || line[i] == '\t' {
+			pairs = append(pairs, logfmtPair{key: key, bare: true})
+			continue
+		}
+		if line[i] != '=' {
+			return nil, false
+		}
+		i++
+
+		// Quoted value with backslash escapes.
+		if i < n && line[i] == '"' {
+			var sb strings.Builder
+			i++
+			closed := false
+			for i < n {
+				c := line[i]
+				if c == '\\' && i+1 < n {
+					switch line[i+1] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 't':
+						sb.WriteByte('\t')
+					case 'r':
+						sb.WriteByte('\r')
+					default:
+						sb.WriteByte(line[i+1])
+					}
+					i += 2
+					continue
+				}
+				if c == '"' {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteByte(c)
+				i++
+			}
+			if !closed || i < n && line[i] != ' ' && line[i] != '\t' {
+				return nil, false
+			}
+			pairs = append(pairs, logfmtPair{key: key, val: sb.String(), quoted: true})
+			continue
+		}
+
+		// Unquoted value runs until whitespace.
+		start = i
+		for i < n && line[i] != ' ' && line[i] != '\t' {
+			i++
+		}
+		pairs = append(pairs, logfmtPair{key: key, val: line[start:i]})
+	}
+
+	return pairs, len(pairs) > 0
+}
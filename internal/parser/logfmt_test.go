@@ -0,0 +1,157 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/model"
+)
+
+func TestLogfmtParser(t *testing.T) {
+	p := NewLogfmtParser()
+
+	entry := p.Parse(`level=warn ts=2026-02-17T12:00:00Z msg="request done" dur=12ms path=/api cached`, "api.log")
+
+	if entry.Level != "WARN" {
+		t.Errorf("expected level WARN, got %s", entry.Level)
+	}
+	if entry.Message != "request done" {
+		t.Errorf("expected message 'request done', got %q", entry.Message)
+	}
+	if entry.Timestamp.Year() != 2026 {
+		t.Errorf("expected year 2026, got %d", entry.Timestamp.Year())
+	}
+	if entry.Fields["dur"].String() != "12ms" {
+		t.Errorf("expected dur 12ms, got %q", entry.Fields["dur"].String())
+	}
+	if entry.Fields["path"].String() != "/api" {
+		t.Errorf("expected path /api, got %q", entry.Fields["path"].String())
+	}
+	if entry.Fields["cached"].String() != "true" {
+		t.Errorf("expected bare key to be true, got %q", entry.Fields["cached"].String())
+	}
+	if _, ok := entry.Fields["msg"]; ok {
+		t.Error("expected msg to be consumed, not stored in fields")
+	}
+
+	// A leading timestamp is the entry's time, not a bare key.
+	for _, line := range []string{
+		`2026-10-16T09:00:00Z level=error msg=boom`,
+		`2026-10-16 09:00:00 level=error msg=boom`,
+		`[2026-10-16 09:00:00] level=error msg=boom`,
+	} {
+		entry = p.Parse(line, "api.log")
+		want := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+		if !entry.TimeParsed || !entry.Timestamp.Equal(want) {
+			t.Errorf("%s: expected timestamp %v, got %v (parsed %v)", line, want, entry.Timestamp, entry.TimeParsed)
+		}
+		if entry.Level != "ERROR" || entry.Message != "boom" || len(entry.Fields) != 0 {
+			t.Errorf("%s: unexpected entry %+v", line, entry)
+		}
+	}
+}
+
+func TestLogfmtParserEscapes(t *testing.T) {
+	p := NewLogfmtParser()
+
+	entry := p.Parse(`lvl=eror msg="said \"hi\"\nbye" err="a=b c"`, "api.log")
+
+	if entry.Level != "ERROR" {
+		t.Errorf("expected level ERROR, got %s", entry.Level)
+	}
+	if entry.Message != "said \"hi\"\nbye" {
+		t.Errorf("unexpected message %q", entry.Message)
+	}
+	if entry.Fields["err"].String() != "a=b c" {
+		t.Errorf("expected err 'a=b c', got %q", entry.Fields["err"].String())
+	}
+}
+
+func TestLogfmtParserMalformed(t *testing.T) {
+	p := NewLogfmtParser()
+
+	for _, line := range []string{
+		`msg="unterminated`,
+		`=value level=info`,
+		`just some words`,
+		`user=bob logged in`,
+		`ERROR failed to connect host=db port=5432`,
+	} {
+		if _, ok := p.parse(line, "x.log"); ok {
+			t.Errorf("expected %q not to be detected as logfmt", line)
+		}
+	}
+}
+
+func TestAutoParserLogfmt(t *testing.T) {
+	p := NewAutoParser()
+
+	entry := p.Parse(`level=error msg="db timeout" retries=3`, "api.log")
+
+	if entry.Level != "ERROR" {
+		t.Errorf("expected ERROR, got %s", entry.Level)
+	}
+	if entry.Message != "db timeout" {
+		t.Errorf("expected 'db timeout', got %q", entry.Message)
+	}
+	if n, ok := entry.Fields["retries"].Int(); !ok || n != 3 {
+		t.Errorf("expected integer retries 3, got %q", entry.Fields["retries"])
+	}
+}
+
+func TestLogfmtParserTypedValues(t *testing.T) {
+	p := NewLogfmtParser()
+
+	entry := p.Parse(`level=info retries=3 dur=0.25 ok=false at=2026-10-16T09:00:00Z code="042" size=12kb`, "api.log")
+
+	want := map[string]model.Kind{
+		"retries": model.KindInt,
+		"dur":     model.KindFloat,
+		"ok":      model.KindBool,
+		"at":      model.KindTime,
+		"code":    model.KindString, // quoted: kept as written
+		"size":    model.KindString,
+	}
+	for key, kind := range want {
+		if got := entry.Fields[key].Kind(); got != kind {
+			t.Errorf("%s: expected %s, got %s (%q)", key, kind, got, entry.Fields[key])
+		}
+	}
+	if f, _ := entry.Fields["dur"].Float(); f != 0.25 {
+		t.Errorf("expected dur 0.25, got %v", f)
+	}
+	if entry.Fields["code"].String() != "042" {
+		t.Errorf("expected code 042, got %q", entry.Fields["code"])
+	}
+}
+
+// Prose with a few k=v pairs is left to the keyword fallback.
+func TestAutoParserProseWithPairs(t *testing.T) {
+	p := NewAutoParser()
+
+	entry := p.Parse("ERROR failed to connect host=db port=5432", "app.log")
+	if entry.Level != "ERROR" {
+		t.Errorf("expected ERROR, got %s", entry.Level)
+	}
+	if _, ok := entry.Fields["failed"]; ok {
+		t.Errorf("expected no bare-word fields, got %v", entry.Fields)
+	}
+
+	entry = p.Parse("2026-10-16 09:00:00 WARN disk almost full mount=/var used=91%", "sys.log")
+	if entry.Level != "WARN" {
+		t.Errorf("expected WARN, got %s", entry.Level)
+	}
+	if want := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC); !entry.TimeParsed || !entry.Timestamp.Equal(want) {
+		t.Errorf("expected timestamp %v, got %v (parsed %v)", want, entry.Timestamp, entry.TimeParsed)
+	}
+}
+
+func TestAutoParserLogfmtLeadingTime(t *testing.T) {
+	entry := NewAutoParser().Parse("2026-10-16T09:00:00Z level=error msg=boom", "api.log")
+	if !entry.TimeParsed || entry.Timestamp.Hour() != 9 || entry.Level != "ERROR" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if _, ok := entry.Fields["2026-10-16T09:00:00Z"]; ok {
+		t.Errorf("expected the timestamp not to be a field, got %v", entry.Fields)
+	}
+}
@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/model"
+)
+
+// ---------------------------------------------------------------------------
+// Nginx Parser (compiled from a log_format string)
+// ---------------------------------------------------------------------------
+
+// NginxCombined is nginx's predefined "combined" log_format.
+const NginxCombined = `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent"`
+
+// nginxPatterns pins variables whose values contain the delimiter that would
+// otherwise end them (e.g. the space inside $time_local).
+var nginxPatterns = map[string]string{
+	"time_local":   `\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}`,
+	"time_iso8601": `\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:Z|[+-]\d{2}:\d{2})`,
+}
+
+// nginxNumeric lists variables whose values are numbers. Values that do not
+// parse, like "-" for an upstream that was not contacted, stay strings.
+var nginxNumeric = map[string]bool{
+	"status":                 true,
+	"body_bytes_sent":        true,
+	"bytes_sent":             true,
+	"request_length":         true,
+	"request_time":           true,
+	"upstream_status":        true,
+	"upstream_response_time": true,
+	"upstream_connect_time":  true,
+	"upstream_header_time":   true,
+	"connection_requests":    true,
+	"server_port":            true,
+	"remote_port":            true,
+}
+
+// NginxParser parses lines written with an nginx log_format directive.
+// Fields are named after the nginx variables ($status → "status"), and
+// $request is additionally split into method, path and protocol.
+type NginxParser struct {
+	re    *regexp.Regexp
+	names []string // variable name per capture group
+	ts    timeFormat
+}
+
+// NewNginxParser compiles an nginx log_format string, for example:
+//
+//	$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent
+func NewNginxParser(logFormat string) (*NginxParser, error) {
+	var expr strings.Builder
+	var names []string
+
+	expr.WriteString("^")
+	rest := logFormat
+	for rest != "" {
+		i := strings.IndexByte(rest, '$')
+		if i < 0 {
+			expr.WriteString(regexp.QuoteMeta(rest))
+			break
+		}
+		expr.WriteString(regexp.QuoteMeta(rest[:i]))
+		rest = rest[i+1:]
+
+		name, n := nginxVariable(rest)
+		if name == "" {
+			return nil, fmt.Errorf("invalid nginx log_format: bad variable at %q", "$"+rest)
+		}
+		rest = rest[n:]
+		names = append(names, name)
+
+		// A variable runs until the first character of the literal after it.
+		switch {
+		case nginxPatterns[name] != "":
+			expr.WriteString("(" + nginxPatterns[name] + ")")
+		case rest == "":
+			expr.WriteString("(.*)")
+		case rest[0] == '$':
+			expr.WriteString(`(\S*?)`)
+		default:
+			expr.WriteString("([^" + regexp.QuoteMeta(rest[:1]) + "]*)")
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("invalid nginx log_format: no $variables in %q", logFormat)
+	}
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid nginx log_format: %w", err)
+	}
+	return &NginxParser{re: re, names: names}, nil
+}
+
+// nginxVariable reads a variable name ("name" or "{name}") from the start of
+// s and returns it with the number of bytes consumed.
+func nginxVariable(s string) (string, int) {
+	if strings.HasPrefix(s, "{") {
+		end := strings.IndexByte(s, '}')
+		if end < 2 {
+			return "", 0
+		}
+		return s[1:end], end + 1
+	}
+	n := 0
+	for n < len(s) && (s[n] == '_' || s[n] >= 'a' && s[n] <= 'z' || s[n] >= 'A' && s[n] <= 'Z' || s[n] >= '0' && s[n] <= '9') {
+		n++
+	}
+	return s[:n], n
+}
+
+func (p *NginxParser) Parse(raw string, source string) model.LogEntry {
+	entry := base(raw, source)
+
+	matches := p.re.FindStringSubmatch(raw)
+	if matches == nil {
+		return entry
+	}
+
+	entry.Fields = make(map[string]model.Value, len(p.names)+3)
+	for i, name := range p.names {
+		val := matches[i+1]
+		if nginxNumeric[name] {
+			entry.Fields[name] = numberValue(val)
+		} else {
+			entry.Fields[name] = model.StringValue(val)
+		}
+
+		switch name {
+		case "status":
+			entry.Level = statusToLevel(val)
+		case "request":
+			entry.Message = val
+			splitRequest(val, entry.Fields)
+		case "time_local":
+			if t, ok := p.ts.parse(val, clfTimeLayout); ok {
+				setTime(&entry, t)
+			}
+		case "time_iso8601":
+			if t, ok := p.ts.parse(val, time.RFC3339); ok {
+				setTime(&entry, t)
+			}
+		}
+	}
+
+	return entry
+}
@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestNginxParserCustomFormat(t *testing.T) {
+	p, err := NewNginxParser(`$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" $request_time`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line := `10.1.2.3 - alice [17/Feb/2026:12:00:00 +0000] "GET /api/users?id=7 HTTP/1.1" 502 157 "-" 0.532`
+	entry := p.Parse(line, "access.log")
+
+	if entry.Level != "ERROR" {
+		t.Errorf("expected ERROR for 502, got %s", entry.Level)
+	}
+	if entry.Message != "GET /api/users?id=7 HTTP/1.1" {
+		t.Errorf("expected request as message, got %q", entry.Message)
+	}
+	if entry.Timestamp.Year() != 2026 {
+		t.Errorf("expected year 2026, got %d", entry.Timestamp.Year())
+	}
+
+	want := map[string]string{
+		"remote_addr":     "10.1.2.3",
+		"remote_user":     "alice",
+		"status":          "502",
+		"body_bytes_sent": "157",
+		"http_referer":    "-",
+		"request_time":    "0.532",
+		"method":          "GET",
+		"path":            "/api/users?id=7",
+		"protocol":        "HTTP/1.1",
+	}
+	for k, v := range want {
+		if entry.Fields[k].String() != v {
+			t.Errorf("field %s: expected %q, got %q", k, v, entry.Fields[k].String())
+		}
+	}
+}
+
+func TestNginxParserCombined(t *testing.T) {
+	p, err := NewNginxParser(NginxCombined)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line := `127.0.0.1 - - [17/Feb/2026:12:00:00 +0000] "GET / HTTP/1.1" 200 5678 "https://example.com/" "Mozilla/5.0 (X11; Linux x86_64)"`
+	entry := p.Parse(line, "access.log")
+
+	if entry.Fields["http_user_agent"].String() != "Mozilla/5.0 (X11; Linux x86_64)" {
+		t.Errorf("unexpected user agent %q", entry.Fields["http_user_agent"].String())
+	}
+	if entry.Level != "INFO" {
+		t.Errorf("expected INFO, got %s", entry.Level)
+	}
+}
+
+func TestNginxParserBracedVariable(t *testing.T) {
+	p, err := NewNginxParser(`${remote_addr}:${server_port} $status`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := p.Parse("10.0.0.1:443 404", "access.log")
+	if entry.Fields["remote_addr"].String() != "10.0.0.1" || entry.Fields["server_port"].String() != "443" {
+		t.Errorf("unexpected fields %v", entry.Fields)
+	}
+	if entry.Level != "WARN" {
+		t.Errorf("expected WARN, got %s", entry.Level)
+	}
+}
+
+func TestNginxParserInvalidFormat(t *testing.T) {
+	for _, f := range []string{"no variables here", "$ broken", "${}"} {
+		if _, err := NewNginxParser(f); err == nil {
+			t.Errorf("expected error for %q", f)
+		}
+	}
+}
@@ -4,13 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/atikulmunna/loom/internal/model"
 )
 
-// Parser converts a raw log line into a structured LogEntry.
+// Parser converts a raw log line into a structured LogEntry. Parse may be
+// called from several goroutines at once (see hub.Options.Workers).
 type Parser interface {
 	Parse(raw string, source string) model.LogEntry
 }
@@ -21,42 +23,74 @@ type Parser interface {
 
 // JSONParser handles JSON-formatted log lines.
 // Recognizes common field names: level, msg/message, timestamp/time/ts.
-type JSONParser struct{}
+// Nested objects are flattened into dotted keys ("http.response.status_code"),
+// which can also be used as level, message or timestamp paths.
+type JSONParser struct {
+	levelKeys     []string
+	messageKeys   []string
+	timeKeys      []string
+	numericLevels bool
+	ts            timeFormat
+}
 
-func NewJSONParser() *JSONParser { return &JSONParser{} }
+func NewJSONParser() *JSONParser {
+	return &JSONParser{
+		levelKeys:   []string{"level", "severity"},
+		messageKeys: []string{"message", "msg"},
+		timeKeys:    []string{"timestamp", "time", "ts"},
+	}
+}
+
+// setKeys replaces the default key lists with user-configured field paths.
+func (p *JSONParser) setKeys(level, message, timestamp string) {
+	if level != "" {
+		p.levelKeys = []string{level}
+	}
+	if message != "" {
+		p.messageKeys = []string{message}
+	}
+	if timestamp != "" {
+		p.timeKeys = []string{timestamp}
+	}
+}
 
 func (p *JSONParser) Parse(raw string, source string) model.LogEntry {
 	entry := base(raw, source)
 
+	// Numbers are decoded as json.Number so large integers stay exact.
 	var data map[string]interface{}
-	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&data); err != nil {
 		return entry // not valid JSON, return as-is
 	}
 
+	data = flatten(data)
+
 	// Extract level.
-	if v, ok := strField(data, "level", "severity"); ok {
-		entry.Level = normalizeLevel(v)
+	if v, k, ok := strField(data, p.levelKeys...); ok {
+		entry.Level = p.level(v)
+		delete(data, k)
 	}
 
 	// Extract message.
-	if v, ok := strField(data, "message", "msg"); ok {
+	if v, k, ok := strField(data, p.messageKeys...); ok {
 		entry.Message = v
+		delete(data, k)
 	}
 
 	// Extract timestamp.
-	if v, ok := strField(data, "timestamp", "time", "ts"); ok {
-		if t, err := time.Parse(time.RFC3339, v); err == nil {
-			entry.Timestamp = t
+	if v, k, ok := strField(data, p.timeKeys...); ok {
+		if t, ok := p.ts.parse(v, time.RFC3339); ok {
+			setTime(&entry, t)
+			delete(data, k)
 		}
 	}
 
 	// Store remaining fields.
-	entry.Fields = make(map[string]string)
-	skip := map[string]bool{"level": true, "severity": true, "message": true, "msg": true, "timestamp": true, "time": true, "ts": true}
+	entry.Fields = make(map[string]model.Value, len(data))
 	for k, v := range data {
-		if !skip[k] {
-			entry.Fields[k] = fmt.Sprintf("%v", v)
-		}
+		entry.Fields[k] = model.ValueOf(v)
 	}
 
 	return entry
@@ -66,15 +100,16 @@ func (p *JSONParser) Parse(raw string, source string) model.LogEntry {
 // CLF Parser (Common Log Format)
 // ---------------------------------------------------------------------------
 
-// CLFParser handles Apache/Nginx Common Log Format lines.
-// Format: host ident authuser [date] "request" status bytes
+// CLFParser handles Apache/Nginx Common and Combined Log Format lines.
+// Format: host ident authuser [date] "request" status bytes ["referer" "user-agent"]
 type CLFParser struct {
 	re *regexp.Regexp
+	ts timeFormat
 }
 
 func NewCLFParser() *CLFParser {
 	return &CLFParser{
-		re: regexp.MustCompile(`^(\S+) (\S+) (\S+) \[([^\]]+)\] "([^"]*)" (\d{3}) (\S+)`),
+		re: regexp.MustCompile(`^(\S+) (\S+) (\S+) \[([^\]]+)\] "([^"]*)" (\d{3}) (\S+)(?: "([^"]*)" "([^"]*)")?`),
 	}
 }
 
@@ -87,8 +122,8 @@ func (p *CLFParser) Parse(raw string, source string) model.LogEntry {
 	}
 
 	// Parse timestamp: 17/Feb/2026:12:00:00 +0000
-	if t, err := time.Parse("02/Jan/2006:15:04:05 -0700", matches[4]); err == nil {
-		entry.Timestamp = t
+	if t, ok := p.ts.parse(matches[4], clfTimeLayout); ok {
+		setTime(&entry, t)
 	}
 
 	// Determine level from HTTP status code.
@@ -96,17 +131,52 @@ func (p *CLFParser) Parse(raw string, source string) model.LogEntry {
 	entry.Level = statusToLevel(status)
 	entry.Message = matches[5] // the request line
 
-	entry.Fields = map[string]string{
-		"host":   matches[1],
-		"ident":  matches[2],
-		"user":   matches[3],
-		"status": status,
-		"bytes":  matches[7],
+	entry.Fields = map[string]model.Value{
+		"host":   model.StringValue(matches[1]),
+		"ident":  model.StringValue(matches[2]),
+		"user":   model.StringValue(matches[3]),
+		"status": numberValue(status),
+		"bytes":  numberValue(matches[7]),
 	}
 
+	// Combined Log Format adds referer and user agent.
+	if strings.HasSuffix(matches[0], `"`) {
+		entry.Fields["referer"] = model.StringValue(matches[8])
+		entry.Fields["user_agent"] = model.StringValue(matches[9])
+	}
+
+	splitRequest(matches[5], entry.Fields)
 	return entry
 }
 
+// clfTimeLayout is the [date] layout used by CLF and nginx $time_local.
+const clfTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// splitRequest breaks "GET /path HTTP/1.1" into method, path and protocol fields.
+func splitRequest(request string, fields map[string]model.Value) {
+	parts := strings.SplitN(request, " ", 3)
+	if len(parts) < 2 {
+		return
+	}
+	fields["method"] = model.StringValue(parts[0])
+	fields["path"] = model.StringValue(parts[1])
+	if len(parts) == 3 {
+		fields["protocol"] = model.StringValue(parts[2])
+	}
+}
+
+// numberValue types a numeric field ("200", "0.012") as an int or float.
+// Anything else, such as CLF's "-" for no bytes, stays a string.
+func numberValue(s string) model.Value {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return model.IntValue(i)
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && s != "" && (s[0] >= '0' && s[0] <= '9' || s[0] == '-') {
+		return model.FloatValue(f)
+	}
+	return model.StringValue(s)
+}
+
 // statusToLevel maps HTTP status codes to log severity levels.
 func statusToLevel(status string) string {
 	if len(status) == 0 {
@@ -128,16 +198,35 @@ func statusToLevel(status string) string {
 
 // RegexParser uses a user-supplied regex with named capture groups.
 // Recognized groups: timestamp, level, message (all optional).
+// The pattern may also use grok references such as %{IP:client} or
+// %{NUMBER:bytes:int}, which are expanded from the grok library.
 type RegexParser struct {
-	re *regexp.Regexp
+	re       *regexp.Regexp
+	captures map[string]grokCapture // generated group name → grok field
+	ts       timeFormat
 }
 
 func NewRegexParser(pattern string) (*RegexParser, error) {
+	return newRegexParser(pattern, grokLibrary)
+}
+
+// newRegexParser compiles pattern, expanding grok references with lib.
+func newRegexParser(pattern string, lib map[string]string) (*RegexParser, error) {
+	p := &RegexParser{}
+	if isGrok(pattern) {
+		expanded, captures, err := compileGrok(pattern, lib)
+		if err != nil {
+			return nil, fmt.Errorf("invalid grok pattern: %w", err)
+		}
+		pattern, p.captures = expanded, captures
+	}
+
 	re, err := regexp.Compile(pattern)
 	if err != nil {
 		return nil, fmt.Errorf("invalid regex pattern: %w", err)
 	}
-	return &RegexParser{re: re}, nil
+	p.re = re
+	return p, nil
 }
 
 func (p *RegexParser) Parse(raw string, source string) model.LogEntry {
@@ -149,14 +238,23 @@ func (p *RegexParser) Parse(raw string, source string) model.LogEntry {
 	}
 
 	names := p.re.SubexpNames()
-	entry.Fields = make(map[string]string)
+	entry.Fields = make(map[string]model.Value)
 
 	for i, name := range names {
 		if i == 0 || name == "" {
 			continue
 		}
 		val := matches[i]
-		entry.Fields[name] = val
+		if c, ok := p.captures[name]; ok {
+			// Alternatives may capture the same field; keep the one that matched.
+			if _, seen := entry.Fields[c.field]; seen && val == "" {
+				continue
+			}
+			name = c.field
+			entry.Fields[name] = grokValue(val, c.typ)
+		} else {
+			entry.Fields[name] = model.StringValue(val)
+		}
 
 		switch name {
 		case "level":
@@ -164,8 +262,8 @@ func (p *RegexParser) Parse(raw string, source string) model.LogEntry {
 		case "message":
 			entry.Message = val
 		case "timestamp":
-			if t, err := time.Parse(time.RFC3339, val); err == nil {
-				entry.Timestamp = t
+			if t, ok := p.ts.parse(val, time.RFC3339); ok {
+				setTime(&entry, t)
 			}
 		}
 	}
@@ -177,16 +275,21 @@ func (p *RegexParser) Parse(raw string, source string) model.LogEntry {
 // Auto Parser (format auto-detection)
 // ---------------------------------------------------------------------------
 
-// AutoParser tries parsers in order: JSON → CLF → keyword fallback.
+// AutoParser tries parsers in order: JSON → syslog → CLF → logfmt → keyword fallback.
 type AutoParser struct {
-	jsonParser *JSONParser
-	clfParser  *CLFParser
+	jsonParser   *JSONParser
+	syslogParser *SyslogParser
+	clfParser    *CLFParser
+	logfmtParser *LogfmtParser
+	ts           timeFormat // for timestamps leading unstructured lines
 }
 
 func NewAutoParser() *AutoParser {
 	return &AutoParser{
-		jsonParser: NewJSONParser(),
-		clfParser:  NewCLFParser(),
+		jsonParser:   NewJSONParser(),
+		syslogParser: NewSyslogParser(SyslogAuto),
+		clfParser:    NewCLFParser(),
+		logfmtParser: NewLogfmtParser(),
 	}
 }
 
@@ -201,14 +304,28 @@ func (p *AutoParser) Parse(raw string, source string) model.LogEntry {
 		}
 	}
 
+	// Try syslog (RFC 5424, then RFC 3164).
+	if entry, ok := p.syslogParser.parse(raw, source); ok {
+		return entry
+	}
+
 	// Try CLF.
 	entry := p.clfParser.Parse(raw, source)
 	if entry.Message != raw { // parsing extracted something
 		return entry
 	}
 
-	// Fallback: keyword-based level detection.
-	return keywordParse(raw, source)
+	// Try logfmt.
+	if entry, ok := p.logfmtParser.parse(raw, source); ok {
+		return entry
+	}
+
+	// Fallback: keyword-based level detection, plus a leading timestamp.
+	entry = keywordParse(raw, source)
+	if t, ok := p.ts.leadingTime(raw); ok {
+		setTime(&entry, t)
+	}
+	return entry
 }
 
 // ---------------------------------------------------------------------------
@@ -248,28 +365,35 @@ func keywordParse(line, source string) model.LogEntry {
 // normalizeLevel normalizes common level strings to a standard set.
 func normalizeLevel(s string) string {
 	switch strings.ToUpper(strings.TrimSpace(s)) {
-	case "FATAL", "CRITICAL", "CRIT":
+	case "FATAL", "CRITICAL", "CRIT", "PANIC", "DPANIC", "ALERT", "EMERGENCY", "EMERG":
 		return "FATAL"
-	case "ERROR", "ERR":
+	case "ERROR", "ERR", "EROR":
 		return "ERROR"
 	case "WARN", "WARNING":
 		return "WARN"
-	case "DEBUG", "TRACE":
+	case "DEBUG", "TRACE", "DBUG":
 		return "DEBUG"
 	default:
 		return "INFO"
 	}
 }
 
-// strField returns the first matching string value from a map.
-func strField(data map[string]interface{}, keys ...string) (string, bool) {
+// strField returns the first matching string value from a map, and its key.
+// Numbers are written out in full, so epoch timestamps survive.
+func strField(data map[string]interface{}, keys ...string) (string, string, bool) {
 	for _, k := range keys {
 		if v, ok := data[k]; ok {
 			s := fmt.Sprintf("%v", v)
+			switch v := v.(type) {
+			case float64:
+				s = strconv.FormatFloat(v, 'f', -1, 64)
+			case map[string]interface{}, []interface{}:
+				s = model.ValueOf(v).String()
+			}
 			if s != "" {
-				return s, true
+				return s, k, true
 			}
 		}
 	}
-	return "", false
+	return "", "", false
 }
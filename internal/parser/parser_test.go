@@ -37,6 +37,25 @@ func TestJSONParserAltFields(t *testing.T) {
 	}
 }
 
+func TestJSONParserTypedFields(t *testing.T) {
+	p := NewJSONParser()
+
+	entry := p.Parse(`{"msg":"done","bytes":1200000,"latency":0.012,"cached":true,"req":{"method":"GET"}}`, "app.log")
+
+	if n, ok := entry.Fields["bytes"].Int(); !ok || n != 1200000 {
+		t.Errorf("expected int bytes 1200000, got %s %q", entry.Fields["bytes"].Kind(), entry.Fields["bytes"])
+	}
+	if f, ok := entry.Fields["latency"].Float(); !ok || f != 0.012 {
+		t.Errorf("expected float latency 0.012, got %q", entry.Fields["latency"])
+	}
+	if b, ok := entry.Fields["cached"].Bool(); !ok || !b {
+		t.Errorf("expected bool cached, got %q", entry.Fields["cached"])
+	}
+	if got := entry.Fields["req.method"].String(); got != "GET" {
+		t.Errorf("expected nested key flattened to req.method, got %v", entry.Fields)
+	}
+}
+
 func TestJSONParserInvalidJSON(t *testing.T) {
 	p := NewJSONParser()
 
@@ -63,14 +82,37 @@ func TestCLFParser(t *testing.T) {
 	if entry.Message != "GET /api/health HTTP/1.1" {
 		t.Errorf("expected request as message, got %q", entry.Message)
 	}
-	if entry.Fields["status"] != "500" {
-		t.Errorf("expected status 500, got %q", entry.Fields["status"])
+	if entry.Fields["status"].String() != "500" {
+		t.Errorf("expected status 500, got %q", entry.Fields["status"].String())
 	}
-	if entry.Fields["host"] != "127.0.0.1" {
-		t.Errorf("expected host 127.0.0.1, got %q", entry.Fields["host"])
+	if entry.Fields["host"].String() != "127.0.0.1" {
+		t.Errorf("expected host 127.0.0.1, got %q", entry.Fields["host"].String())
 	}
-	if entry.Fields["user"] != "frank" {
-		t.Errorf("expected user frank, got %q", entry.Fields["user"])
+	if entry.Fields["user"].String() != "frank" {
+		t.Errorf("expected user frank, got %q", entry.Fields["user"].String())
+	}
+}
+
+func TestCLFParserCombined(t *testing.T) {
+	p := NewCLFParser()
+
+	line := `127.0.0.1 - - [17/Feb/2026:12:00:00 +0000] "POST /api/login?next=/ HTTP/2.0" 401 12 "https://example.com/" "curl/8.5.0"`
+	entry := p.Parse(line, "access.log")
+
+	if entry.Level != "WARN" {
+		t.Errorf("expected WARN for status 401, got %s", entry.Level)
+	}
+	want := map[string]string{
+		"referer":    "https://example.com/",
+		"user_agent": "curl/8.5.0",
+		"method":     "POST",
+		"path":       "/api/login?next=/",
+		"protocol":   "HTTP/2.0",
+	}
+	for k, v := range want {
+		if entry.Fields[k].String() != v {
+			t.Errorf("field %s: expected %q, got %q", k, v, entry.Fields[k].String())
+		}
 	}
 }
 
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/atikulmunna/loom/internal/model"
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// ---------------------------------------------------------------------------
+// Router (per-source parser selection)
+// ---------------------------------------------------------------------------
+
+// Route binds a glob pattern to the parser used for matching sources.
+// Patterns without a path separator are matched against the file name only.
+type Route struct {
+	Glob   string
+	Parser Parser
+}
+
+// Router dispatches each line to a parser chosen by its source.
+// Routes are tried in order; sources that match none use the fallback.
+type Router struct {
+	routes   []Route
+	fallback Parser
+
+	mu    sync.RWMutex
+	cache map[string]Parser // source → resolved parser
+}
+
+func NewRouter(routes []Route, fallback Parser) *Router {
+	return &Router{
+		routes:   routes,
+		fallback: fallback,
+		cache:    make(map[string]Parser),
+	}
+}
+
+func (r *Router) Parse(raw string, source string) model.LogEntry {
+	return r.parserFor(source).Parse(raw, source)
+}
+
+// parserFor resolves (and caches) the parser for a source.
+func (r *Router) parserFor(source string) Parser {
+	r.mu.RLock()
+	p, ok := r.cache[source]
+	r.mu.RUnlock()
+	if ok {
+		return p
+	}
+
+	p = r.fallback
+	for _, route := range r.routes {
+		if matchSource(route.Glob, source) {
+			p = route.Parser
+			break
+		}
+	}
+
+	r.mu.Lock()
+	r.cache[source] = p
+	r.mu.Unlock()
+	return p
+}
+
+// matchSource reports whether source matches a route glob.
+func matchSource(glob, source string) bool {
+	if !strings.ContainsAny(glob, `/\`) {
+		source = filepath.Base(source)
+	}
+	ok, _ := doublestar.PathMatch(filepath.FromSlash(glob), source)
+	return ok
+}
@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestRouterDispatchesBySource(t *testing.T) {
+	r := NewRouter([]Route{
+		{Glob: "/var/log/nginx/*.log", Parser: NewCLFParser()},
+		{Glob: "/srv/app/**/*.log", Parser: NewJSONParser()},
+		{Glob: "*.logfmt", Parser: NewLogfmtParser()},
+	}, NewAutoParser())
+
+	clf := `10.0.0.1 - - [17/Feb/2026:12:00:00 +0000] "GET / HTTP/1.1" 500 0`
+	if e := r.Parse(clf, "/var/log/nginx/access.log"); e.Level != "ERROR" || e.Fields["status"].String() != "500" {
+		t.Errorf("expected CLF parse for nginx source, got level=%s fields=%v", e.Level, e.Fields)
+	}
+
+	// A JSON-only route must not fall back to CLF detection.
+	if e := r.Parse(clf, "/srv/app/api/server.log"); e.Message != clf {
+		t.Errorf("expected JSON parser to leave CLF line untouched, got %q", e.Message)
+	}
+
+	if e := r.Parse(`level=warn msg=slow`, "/tmp/x/app.logfmt"); e.Level != "WARN" {
+		t.Errorf("expected basename route to match, got level=%s", e.Level)
+	}
+
+	if e := r.Parse(`{"level":"error","message":"boom"}`, "/other/file.log"); e.Message != "boom" {
+		t.Errorf("expected fallback auto parser, got %q", e.Message)
+	}
+}
+
+func TestNewConfigOverrides(t *testing.T) {
+	p, err := New(Config{
+		Format:          "json",
+		LevelField:      "sev",
+		MessageField:    "text",
+		TimestampField:  "at",
+		TimestampLayout: "2006-01-02 15:04:05",
+		Timezone:        "America/New_York",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := p.Parse(`{"sev":"error","text":"boom","at":"2026-02-17 12:00:00","level":"info"}`, "app.log")
+
+	if entry.Level != "ERROR" || entry.Message != "boom" {
+		t.Errorf("expected custom keys to be used, got level=%s message=%q", entry.Level, entry.Message)
+	}
+	if got := entry.Timestamp.UTC().Hour(); got != 17 {
+		t.Errorf("expected 12:00 New York = 17:00 UTC, got hour %d", got)
+	}
+	if entry.Fields["level"].String() != "info" {
+		t.Errorf("expected unmapped 'level' key kept as a field, got %v", entry.Fields)
+	}
+}
+
+func TestNewConfigErrors(t *testing.T) {
+	for _, cfg := range []Config{
+		{Format: "regex"},
+		{Format: "nope"},
+		{Format: "json", Timezone: "Mars/Olympus"},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/model"
+)
+
+// ---------------------------------------------------------------------------
+// Syslog Parser (RFC 5424 and RFC 3164)
+// ---------------------------------------------------------------------------
+
+// SyslogVariant selects which syslog wire format a SyslogParser accepts.
+type SyslogVariant int
+
+const (
+	// SyslogAuto accepts both formats, trying RFC 5424 first.
+	SyslogAuto SyslogVariant = iota
+	// SyslogRFC3164 accepts BSD syslog: <PRI>Mmm dd hh:mm:ss host tag[pid]: msg
+	SyslogRFC3164
+	// SyslogRFC5424 accepts <PRI>1 TIMESTAMP HOST APP PROCID MSGID [SD] MSG
+	SyslogRFC5424
+)
+
+var syslogFacilities = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+var syslogSeverities = []string{
+	"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug",
+}
+
+// SyslogParser handles syslog lines from /var/log/syslog, /var/log/messages
+// and network appliances. The priority is decoded into facility and severity,
+// and RFC 5424 structured data is flattened into "sdid.param" fields.
+type SyslogParser struct {
+	variant SyslogVariant
+	bsd     *regexp.Regexp
+	tag     *regexp.Regexp
+	ts      timeFormat
+}
+
+func NewSyslogParser(variant SyslogVariant) *SyslogParser {
+	return &SyslogParser{
+		variant: variant,
+		// <PRI> is optional: files written by rsyslog usually omit it.
+		// The timestamp is either "Jan _2 15:04:05" or RFC 3339 (high-precision mode).
+		bsd: regexp.MustCompile(`^(?:<(\d{1,3})>)?([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}|\d{4}-\d{2}-\d{2}T\S+) (\S+) ?(.*)$`),
+		tag: regexp.MustCompile(`^([^:\[\s]+)(?:\[([^\]]*)\])?: ?(.*)$`),
+	}
+}
+
+func (p *SyslogParser) Parse(raw string, source string) model.LogEntry {
+	entry, _ := p.parse(raw, source)
+	return entry
+}
+
+// parse returns the entry and whether the line was recognized as syslog.
+func (p *SyslogParser) parse(raw string, source string) (model.LogEntry, bool) {
+	if p.variant != SyslogRFC3164 {
+		if entry, ok := p.parse5424(raw, source); ok {
+			return entry, true
+		}
+	}
+	if p.variant != SyslogRFC5424 {
+		if entry, ok := p.parse3164(raw, source); ok {
+			return entry, true
+		}
+	}
+	return base(raw, source), false
+}
+
+// parse5424 decodes an RFC 5424 line.
+func (p *SyslogParser) parse5424(raw, source string) (model.LogEntry, bool) {
+	entry := base(raw, source)
+
+	pri, rest, ok := syslogPRI(raw)
+	if !ok || !strings.HasPrefix(rest, "1 ") {
+		return entry, false
+	}
+	rest = rest[2:]
+
+	// TIMESTAMP HOSTNAME APP-NAME PROCID MSGID
+	var header [5]string
+	for i := range header {
+		var tok string
+		tok, rest, ok = strings.Cut(rest, " ")
+		if !ok || tok == "" {
+			return entry, false
+		}
+		header[i] = tok
+	}
+
+	fields := make(map[string]model.Value)
+	setSyslogPRI(&entry, fields, pri)
+
+	if header[0] != "-" {
+		if t, ok := p.ts.parse(header[0], time.RFC3339Nano); ok {
+			setTime(&entry, t)
+		}
+	}
+	for i, name := range []string{"hostname", "appname", "procid", "msgid"} {
+		if v := header[i+1]; v != "-" {
+			fields[name] = model.StringValue(v)
+		}
+	}
+
+	// STRUCTURED-DATA is "-" or one or more [SDID param="value" ...] elements.
+	if strings.HasPrefix(rest, "-") {
+		rest = rest[1:]
+	} else if strings.HasPrefix(rest, "[") {
+		rest, ok = parseStructuredData(rest, fields)
+		if !ok {
+			return base(raw, source), false
+		}
+	} else if rest != "" {
+		return base(raw, source), false
+	}
+
+	msg := strings.TrimPrefix(strings.TrimPrefix(rest, " "), "\ufeff")
+	entry.Message = msg
+	entry.Fields = fields
+	return entry, true
+}
+
+// parse3164 decodes a BSD syslog line.
+func (p *SyslogParser) parse3164(raw, source string) (model.LogEntry, bool) {
+	m := p.bsd.FindStringSubmatch(raw)
+	if m == nil {
+		return base(raw, source), false
+	}
+
+	entry := keywordParse(raw, source)
+	fields := make(map[string]model.Value)
+
+	if m[1] != "" {
+		pri, err := strconv.Atoi(m[1])
+		if err != nil || pri > 191 {
+			return base(raw, source), false
+		}
+		setSyslogPRI(&entry, fields, pri)
+	}
+
+	if ts, ok := p.parseBSDTime(m[2]); ok {
+		setTime(&entry, ts)
+	}
+	fields["hostname"] = model.StringValue(m[3])
+
+	msg := m[4]
+	t := p.tag.FindStringSubmatch(msg)
+	if t != nil {
+		fields["appname"] = model.StringValue(t[1])
+		if t[2] != "" {
+			fields["procid"] = model.StringValue(t[2])
+		}
+		msg = t[3]
+	} else if m[1] == "" && m[2][0] >= '0' && m[2][0] <= '9' {
+		// "2026-02-17T12:00:00Z ERROR boom" is a plain line, not syslog:
+		// without a PRI, the RFC 3339 form is only accepted with a tag.
+		return base(raw, source), false
+	}
+
+	entry.Message = msg
+	entry.Fields = fields
+	return entry, true
+}
+
+// syslogPRI extracts the leading <PRI> value.
+func syslogPRI(raw string) (int, string, bool) {
+	if !strings.HasPrefix(raw, "<") {
+		return 0, raw, false
+	}
+	end := strings.IndexByte(raw, '>')
+	if end < 2 || end > 4 {
+		return 0, raw, false
+	}
+	pri, err := strconv.Atoi(raw[1:end])
+	if err != nil || pri > 191 {
+		return 0, raw, false
+	}
+	return pri, raw[end+1:], true
+}
+
+// setSyslogPRI records facility and severity and maps severity onto Loom levels.
+func setSyslogPRI(entry *model.LogEntry, fields map[string]model.Value, pri int) {
+	facility, severity := pri/8, pri%8
+	fields["facility"] = model.StringValue(syslogFacilities[facility])
+	fields["severity"] = model.StringValue(syslogSeverities[severity])
+
+	switch {
+	case severity <= 2: // emerg, alert, crit
+		entry.Level = "FATAL"
+	case severity == 3:
+		entry.Level = "ERROR"
+	case severity == 4:
+		entry.Level = "WARN"
+	case severity == 7:
+		entry.Level = "DEBUG"
+	default: // notice, info
+		entry.Level = "INFO"
+	}
+}
+
+// parseStructuredData consumes consecutive SD elements from s and stores each
+// parameter as "sdid.name". It returns the remainder of the line.
+func parseStructuredData(s string, fields map[string]model.Value) (string, bool) {
+	for strings.HasPrefix(s, "[") {
+		i := 1
+		for i < len(s) && s[i] != ' ' && s[i] != ']' {
+			i++
+		}
+		if i >= len(s) {
+			return s, false
+		}
+		id := s[1:i]
+
+		for i < len(s) && s[i] == ' ' {
+			i++
+			eq := strings.IndexByte(s[i:], '=')
+			if eq < 1 || i+eq+1 >= len(s) || s[i+eq+1] != '"' {
+				return s, false
+			}
+			name := s[i : i+eq]
+			i += eq + 2
+
+			var sb strings.Builder
+			for i < len(s) && s[i] != '"' {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`"\]`, s[i+1]) >= 0 {
+					i++
+				}
+				sb.WriteByte(s[i])
+				i++
+			}
+			if i >= len(s) {
+				return s, false
+			}
+			i++ // closing quote
+			fields[id+"."+name] = model.StringValue(sb.String())
+		}
+
+		if i >= len(s) || s[i] != ']' {
+			return s, false
+		}
+		s = s[i+1:]
+	}
+	return s, true
+}
+
+// parseBSDTime parses an RFC 3164 timestamp (see parseStamp for the year).
+// Zone-less stamps default to local time.
+func (p *SyslogParser) parseBSDTime(s string) (time.Time, bool) {
+	if len(s) > 0 && s[0] >= '0' && s[0] <= '9' {
+		return p.ts.parse(s, time.RFC3339Nano)
+	}
+	return parseStamp(s, p.ts.location(time.Local))
+}
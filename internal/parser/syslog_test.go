@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSyslogRFC5424(t *testing.T) {
+	p := NewSyslogParser(SyslogRFC5424)
+
+	line := `<165>1 2026-02-17T12:00:00.003Z mymachine.example.com evntslog 1234 ID47 [exampleSDID@32473 iut="3" eventSource="Application" eventID="1011"][meta seq="a\"b"] An application event`
+	entry := p.Parse(line, "syslog")
+
+	if entry.Level != "INFO" { // 165 = local4.notice
+		t.Errorf("expected level INFO, got %s", entry.Level)
+	}
+	if entry.Message != "An application event" {
+		t.Errorf("unexpected message %q", entry.Message)
+	}
+	if entry.Timestamp.Year() != 2026 {
+		t.Errorf("expected year 2026, got %d", entry.Timestamp.Year())
+	}
+
+	want := map[string]string{
+		"facility":                      "local4",
+		"severity":                      "notice",
+		"hostname":                      "mymachine.example.com",
+		"appname":                       "evntslog",
+		"procid":                        "1234",
+		"msgid":                         "ID47",
+		"exampleSDID@32473.iut":         "3",
+		"exampleSDID@32473.eventSource": "Application",
+		"meta.seq":                      `a"b`,
+	}
+	for k, v := range want {
+		if entry.Fields[k].String() != v {
+			t.Errorf("field %s: expected %q, got %q", k, v, entry.Fields[k].String())
+		}
+	}
+}
+
+func TestSyslogRFC5424NilValues(t *testing.T) {
+	p := NewSyslogParser(SyslogRFC5424)
+
+	entry := p.Parse(`<11>1 - - - - - - disk failure`, "syslog")
+
+	if entry.Level != "ERROR" { // 11 = user.err
+		t.Errorf("expected ERROR, got %s", entry.Level)
+	}
+	if entry.Message != "disk failure" {
+		t.Errorf("unexpected message %q", entry.Message)
+	}
+	if _, ok := entry.Fields["hostname"]; ok {
+		t.Error("expected nil hostname to be omitted")
+	}
+}
+
+func TestSyslogRFC3164(t *testing.T) {
+	p := NewSyslogParser(SyslogRFC3164)
+
+	entry := p.Parse(`<34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed for lonvick on /dev/pts/8`, "syslog")
+
+	if entry.Level != "FATAL" { // 34 = auth.crit
+		t.Errorf("expected FATAL, got %s", entry.Level)
+	}
+	if entry.Message != "'su root' failed for lonvick on /dev/pts/8" {
+		t.Errorf("unexpected message %q", entry.Message)
+	}
+	if entry.Fields["hostname"].String() != "mymachine" || entry.Fields["appname"].String() != "su" || entry.Fields["procid"].String() != "230" {
+		t.Errorf("unexpected header fields: %v", entry.Fields)
+	}
+	if entry.Fields["facility"].String() != "auth" {
+		t.Errorf("expected facility auth, got %q", entry.Fields["facility"].String())
+	}
+	if entry.Timestamp.Month() != time.October || entry.Timestamp.Day() != 11 {
+		t.Errorf("unexpected timestamp %v", entry.Timestamp)
+	}
+}
+
+func TestSyslogRFC3164WithoutPRI(t *testing.T) {
+	p := NewSyslogParser(SyslogAuto)
+
+	entry := p.Parse(`Feb  7 09:01:02 web01 sshd[811]: error: maximum authentication attempts exceeded`, "/var/log/auth.log")
+
+	if entry.Level != "ERROR" {
+		t.Errorf("expected keyword level ERROR, got %s", entry.Level)
+	}
+	if entry.Fields["appname"].String() != "sshd" {
+		t.Errorf("expected appname sshd, got %q", entry.Fields["appname"].String())
+	}
+	if _, ok := entry.Fields["facility"]; ok {
+		t.Error("expected no facility without PRI")
+	}
+}
+
+func TestAutoParserSyslog(t *testing.T) {
+	p := NewAutoParser()
+
+	entry := p.Parse(`<12>1 2026-02-17T12:00:00Z host app - - - low disk`, "syslog")
+	if entry.Level != "WARN" || entry.Message != "low disk" {
+		t.Errorf("expected RFC 5424 detection, got level=%s message=%q", entry.Level, entry.Message)
+	}
+
+	entry = p.Parse(`Feb 17 12:00:00 host cron[1]: job started`, "syslog")
+	if entry.Message != "job started" || entry.Fields["appname"].String() != "cron" {
+		t.Errorf("expected RFC 3164 detection, got message=%q fields=%v", entry.Message, entry.Fields)
+	}
+}
+
+func TestAutoParserPlainISOLineIsNotSyslog(t *testing.T) {
+	p := NewAutoParser()
+
+	entry := p.Parse("2026-02-17T12:00:00Z ERROR failed to process item 7", "app.log")
+
+	if _, ok := entry.Fields["hostname"]; ok {
+		t.Errorf("plain line misdetected as syslog: %v", entry.Fields)
+	}
+	if entry.Level != "ERROR" {
+		t.Errorf("expected ERROR, got %s", entry.Level)
+	}
+}
@@ -0,0 +1,214 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/model"
+)
+
+// ---------------------------------------------------------------------------
+// Timestamp detection
+// ---------------------------------------------------------------------------
+
+// detectLayouts are the layouts tried when a timestamp does not match the
+// parser's own layout. Fractional seconds ("05.123" or "05,123") are accepted
+// after any seconds field.
+var detectLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006/01/02 15:04:05",
+	clfTimeLayout,
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RubyDate,
+	time.UnixDate,
+	time.ANSIC,
+}
+
+// setTime records a timestamp read from the line itself.
+func setTime(entry *model.LogEntry, t time.Time) {
+	entry.Timestamp = t
+	entry.TimeParsed = true
+}
+
+// detectTime recognizes a timestamp in any of the common layouts, a BSD
+// syslog stamp ("Jan _2 15:04:05") or a numeric Unix epoch in seconds,
+// milliseconds, microseconds or nanoseconds. Zone-less stamps use loc.
+func detectTime(s string, loc *time.Location) (time.Time, bool) {
+	if t, ok := parseEpoch(s); ok {
+		return t, true
+	}
+	if t, ok := parseLayouts(s, loc); ok {
+		return t, true
+	}
+	return parseStamp(s, loc)
+}
+
+// parseLayouts tries the layouts in detectLayouts.
+func parseLayouts(s string, loc *time.Location) (time.Time, bool) {
+	for _, layout := range detectLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseStamp parses a BSD syslog timestamp. The format carries no year, so
+// the current one is assumed, stepping back a year for stamps in the future
+// (December lines read in January).
+func parseStamp(s string, loc *time.Location) (time.Time, bool) {
+	t, err := time.ParseInLocation(time.Stamp, s, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	now := time.Now()
+	year := now.Year()
+	stamp := func(y int) time.Time {
+		return time.Date(y, t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+	}
+	if stamp(year).After(now.Add(24 * time.Hour)) {
+		year--
+	}
+	return stamp(year), true
+}
+
+// parseEpoch reads a Unix epoch. The unit follows from the number of integer
+// digits: 9-10 seconds, 12-13 milliseconds, 15-16 microseconds, 18-19
+// nanoseconds. Other lengths are too ambiguous to be timestamps.
+func parseEpoch(s string) (time.Time, bool) {
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if !isDigits(whole) || hasFrac && !isDigits(frac) {
+		return time.Time{}, false
+	}
+
+	var unit time.Duration
+	switch n := len(whole); {
+	case n >= 9 && n <= 10:
+		unit = time.Second
+	case n >= 12 && n <= 13:
+		unit = time.Millisecond
+	case n >= 15 && n <= 16:
+		unit = time.Microsecond
+	case n >= 18 && n <= 19:
+		unit = time.Nanosecond
+	default:
+		return time.Time{}, false
+	}
+
+	v, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	t := time.Unix(0, 0).Add(time.Duration(v) * unit)
+	if hasFrac && unit > time.Nanosecond {
+		if f, err := strconv.ParseFloat("0."+frac, 64); err == nil {
+			t = t.Add(time.Duration(f * float64(unit)))
+		}
+	}
+	return t.UTC(), true
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// leadingTime finds a timestamp at the start of an unstructured line, either
+// in brackets ("[2026-10-16 09:00:00] ...") or as its first few words.
+// Numeric epochs are not considered, since lines often start with numbers.
+func (f timeFormat) leadingTime(line string) (time.Time, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return time.Time{}, false
+	}
+	if line[0] == '[' {
+		if end := strings.IndexByte(line, ']'); end > 0 {
+			return f.parseText(line[1:end], 0)
+		}
+		return time.Time{}, false
+	}
+	if c := line[0]; !(c >= '0' && c <= '9' || c >= 'A' && c <= 'Z') {
+		return time.Time{}, false // every layout starts with a digit or a name
+	}
+
+	words := strings.Fields(line)
+	if len(words) > 6 {
+		words = words[:6]
+	}
+	for i, w := range words {
+		if !timeWord(w) {
+			words = words[:i]
+			break
+		}
+	}
+	// Every built-in layout has a clock time, so the timestamp ends at or
+	// after the first word with a colon. User layouts may be date-only.
+	first := len(words)
+	for i, w := range words {
+		if strings.IndexByte(w, ':') >= 0 {
+			first = i
+			break
+		}
+	}
+	if len(f.layouts) > 0 {
+		first = 0
+	}
+	for n := len(words); n > first; n-- {
+		if t, ok := f.parseText(strings.Join(words[:n], " "), n); ok {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseText parses a timestamp that is not a bare number. With words set,
+// only layouts of that many words are tried.
+func (f timeFormat) parseText(s string, words int) (time.Time, bool) {
+	loc := f.location(time.UTC)
+	try := func(layout string) (time.Time, bool) {
+		if words > 0 && layoutWords(layout) != words {
+			return time.Time{}, false
+		}
+		t, err := time.ParseInLocation(layout, s, loc)
+		return t, err == nil
+	}
+	for _, layout := range f.layouts {
+		if t, ok := try(layout); ok {
+			return t, true
+		}
+	}
+	for _, layout := range detectLayouts {
+		if t, ok := try(layout); ok {
+			return t, true
+		}
+	}
+	if words > 0 && words != layoutWords(time.Stamp) {
+		return time.Time{}, false
+	}
+	return parseStamp(s, f.location(time.Local))
+}
+
+// layoutWords returns the number of space-separated words in a layout.
+func layoutWords(layout string) int {
+	return strings.Count(layout, " ") + 1
+}
+
+// timeWord reports whether w can be part of a timestamp: it has a digit, or
+// is short enough to be a month, weekday or zone name ("Jan", "Mon,", "CEST").
+func timeWord(w string) bool {
+	return len(strings.TrimSuffix(w, ",")) <= 4 || strings.ContainsAny(w, "0123456789")
+}
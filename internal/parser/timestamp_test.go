@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetectTime(t *testing.T) {
+	want := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2026-10-16T09:00:00Z", want},
+		{"2026-10-16T09:00:00.000+00:00", want},
+		{"2026-10-16T09:00:00+0000", want},
+		{"2026-10-16 09:00:00", want},
+		{"2026-10-16 09:00:00,250", want.Add(250 * time.Millisecond)},
+		{"2026-10-16 09:00:00.250", want.Add(250 * time.Millisecond)},
+		{"2026/10/16 09:00:00", want},
+		{"16/Oct/2026:09:00:00 +0000", want},
+		{"Fri, 16 Oct 2026 09:00:00 +0000", want},
+		{"1792141200", want},
+		{"1792141200.5", want.Add(500 * time.Millisecond)},
+		{"1792141200000", want},
+		{"1792141200000000", want},
+		{"1792141200000000000", want},
+	}
+
+	for _, tt := range tests {
+		got, ok := detectTime(tt.in, time.UTC)
+		if !ok || !got.Equal(tt.want) {
+			t.Errorf("detectTime(%q) = %v, %v; want %v", tt.in, got, ok, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "42", "12345678901", "yesterday", "2026-13-45"} {
+		if got, ok := detectTime(in, time.UTC); ok {
+			t.Errorf("detectTime(%q) = %v; want no timestamp", in, got)
+		}
+	}
+}
+
+func TestDetectBSDStamp(t *testing.T) {
+	got, ok := detectTime("Jan  2 15:04:05", time.UTC)
+	if !ok || got.Month() != time.January || got.Day() != 2 || got.Hour() != 15 {
+		t.Errorf("expected Jan 2 15:04:05, got %v (ok=%v)", got, ok)
+	}
+	if got.After(time.Now().Add(24 * time.Hour)) {
+		t.Errorf("expected the year to be inferred into the past, got %v", got)
+	}
+}
+
+func TestTimeParsedFlag(t *testing.T) {
+	p := NewAutoParser()
+	want := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		line   string
+		parsed bool
+	}{
+		{`{"ts":1792141200123,"msg":"epoch millis"}`, true},
+		{`{"time":"2026-10-16 09:00:00","msg":"space layout"}`, true},
+		{"2026-10-16 09:00:00,000 ERROR plain line", true},
+		{"[2026-10-16T09:00:00Z] WARN bracketed", true},
+		{"Fri, 16 Oct 2026 09:00:00 UTC request failed", true},
+		{"2026/10/16 09:00:00 connection refused: 10.0.0.1:5432", true},
+		{"just a line", false},
+		{"    at com.example.Foo.bar(Foo.java:42)", false},
+	}
+
+	for _, tt := range tests {
+		entry := p.Parse(tt.line, "app.log")
+		if entry.TimeParsed != tt.parsed {
+			t.Errorf("%q: expected TimeParsed=%v, got %v", tt.line, tt.parsed, entry.TimeParsed)
+			continue
+		}
+		if tt.parsed && entry.Timestamp.Truncate(time.Second) != want {
+			t.Errorf("%q: expected %v, got %v", tt.line, want, entry.Timestamp)
+		}
+	}
+}
+
+func TestTimestampLayoutsAndZone(t *testing.T) {
+	p, err := New(Config{
+		Format:           "regex",
+		Pattern:          `^(?P<timestamp>\S+ \S+) (?P<message>.+)$`,
+		TimestampLayouts: []string{"02.01.2006 15:04"},
+		Timezone:         "Europe/Berlin",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := p.Parse("16.10.2026 11:00 started", "app.log")
+	want := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	if !entry.TimeParsed || !entry.Timestamp.Equal(want) {
+		t.Errorf("expected %v, got %v (parsed=%v)", want, entry.Timestamp, entry.TimeParsed)
+	}
+}
@@ -0,0 +1,611 @@
+// Package query implements Loom's filter expressions, e.g.
+//
+//	level>=WARN and status>=500 and path =~ "^/api" and not message contains "healthcheck"
+//
+// An expression is a comparison, a bare field (true when the field is set
+// and not false, zero or empty), or a combination of them with and, or, not
+// and parentheses. The left side of a comparison names a field; the right
+// side is a literal, quoted or bare.
+//
+// Fields are level, message, source, raw, timestamp (or time), late and
+// time_parsed; any other name refers to a parsed field ("status",
+// "http.response.status_code", optionally written fields.status).
+//
+// Operators:
+//
+//	== (or =), !=, <, <=, >, >=   compare; numbers numerically, levels by
+//	                              severity (DEBUG < INFO < WARN < ERROR < FATAL),
+//	                              timestamps as times, anything else as text
+//	=~, !~                        regular expression match
+//	contains                      substring
+//	glob                          glob match; patterns without a / match
+//	                              the file name only ("source glob *.log")
+//
+// Times are written as a duration before now ("15m") or as a time
+// ("2026-10-16T09:00", in UTC unless it has an offset or ParseIn is given
+// another zone); relative times are fixed when the expression is parsed. A comparison on a field the entry does not have is false, except
+// != and !~, which are true.
+package query
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/model"
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// Expr is a parsed filter expression. It is safe for concurrent use.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse compiles an expression, reading times without an offset in UTC.
+func Parse(s string) (*Expr, error) {
+	return ParseIn(s, time.UTC)
+}
+
+// ParseIn is Parse with times without an offset read in loc, the zone
+// assumed for log timestamps without one.
+func ParseIn(s string, loc *time.Location) (*Expr, error) {
+	toks, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{toks: toks, now: time.Now(), loc: loc}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "unexpected %q", t.text)
+	}
+	return &Expr{src: s, root: root}, nil
+}
+
+// Match reports whether an entry satisfies the expression.
+func (e *Expr) Match(entry model.LogEntry) bool {
+	return e.root.eval(&entry)
+}
+
+// String returns the source text of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// SyntaxError describes an invalid expression.
+type SyntaxError struct {
+	Pos int // byte offset in the expression
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s (at offset %d)", e.Msg, e.Pos)
+}
+
+// ---------------------------------------------------------------------------
+// Lexer
+// ---------------------------------------------------------------------------
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// lex splits an expression into words, quoted strings, operators and
+// parentheses. Bare words run until whitespace, a quote, a parenthesis or
+// an operator character.
+func lex(s string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			toks = append(toks, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			toks = append(toks, token{tokRParen, ")", i})
+			i++
+		case c == '"' || c == '\'':
+			text, n, err := lexString(s[i:])
+			if err != nil {
+				return nil, &SyntaxError{Pos: i, Msg: err.Error()}
+			}
+			toks = append(toks, token{tokString, text, i})
+			i += n
+		case isOpChar(c):
+			op := lexOp(s[i:])
+			if op == "" {
+				return nil, &SyntaxError{Pos: i, Msg: fmt.Sprintf("unexpected %q", c)}
+			}
+			text := op
+			if text == "=" {
+				text = "=="
+			}
+			toks = append(toks, token{tokOp, text, i})
+			i += len(op)
+		default:
+			start := i
+			for i < len(s) && !isWordEnd(s[i]) {
+				i++
+			}
+			toks = append(toks, token{tokWord, s[start:i], start})
+		}
+	}
+	return append(toks, token{tokEOF, "", len(s)}), nil
+}
+
+func isOpChar(c byte) bool {
+	return c == '=' || c == '!' || c == '<' || c == '>' || c == '~'
+}
+
+func isWordEnd(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '(' || c == ')' ||
+		c == '"' || c == '\'' || isOpChar(c)
+}
+
+// lexOp returns the operator at the start of s, or "".
+func lexOp(s string) string {
+	for _, op := range []string{"==", "!=", "<=", ">=", "=~", "!~", "<", ">", "="} {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// lexString reads a quoted string. Backslash escapes the quote and the
+// backslash itself; other escapes are kept as written, so regexes like
+// "\d+" need no doubling.
+func lexString(s string) (string, int, error) {
+	quote := s[0]
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == quote || s[i+1] == '\\'):
+			sb.WriteByte(s[i+1])
+			i++
+		case c == quote:
+			return sb.String(), i + 1, nil
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+// ---------------------------------------------------------------------------
+// Parser
+// ---------------------------------------------------------------------------
+
+type exprParser struct {
+	toks []token
+	i    int
+	now  time.Time
+	loc  *time.Location
+}
+
+func (p *exprParser) peek() token { return p.toks[p.i] }
+
+func (p *exprParser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *exprParser) errorf(t token, format string, a ...any) error {
+	return &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf(format, a...)}
+}
+
+// keyword reports whether t is the given keyword (case-insensitive).
+func keyword(t token, kw string) bool {
+	return t.kind == tokWord && strings.EqualFold(t.text, kw)
+}
+
+func (p *exprParser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for keyword(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for keyword(p.peek(), "and") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseNot() (node, error) {
+	if keyword(p.peek(), "not") {
+		p.next()
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (node, error) {
+	t := p.next()
+	switch {
+	case t.kind == tokLParen:
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if r := p.next(); r.kind != tokRParen {
+			return nil, p.errorf(r, "expected )")
+		}
+		return x, nil
+	case t.kind == tokEOF:
+		return nil, p.errorf(t, "unexpected end of expression")
+	case t.kind != tokWord || isKeyword(t.text):
+		return nil, p.errorf(t, "expected a field name, got %q", t.text)
+	}
+
+	f := newField(t.text)
+	op := p.peek()
+	switch {
+	case op.kind == tokOp:
+	case keyword(op, "contains"), keyword(op, "glob"):
+		op.text = strings.ToLower(op.text)
+	default:
+		return truthyNode{f}, nil // bare field
+	}
+	p.next()
+
+	lit := p.next()
+	if lit.kind != tokWord && lit.kind != tokString {
+		return nil, p.errorf(lit, "expected a value after %s", op.text)
+	}
+	return p.newCompare(f, op, lit)
+}
+
+func isKeyword(s string) bool {
+	switch strings.ToLower(s) {
+	case "and", "or", "not", "contains", "glob":
+		return true
+	}
+	return false
+}
+
+// newCompare prepares a comparison, checking the literal once up front.
+func (p *exprParser) newCompare(f field, op, lit token) (node, error) {
+	c := &compareNode{f: f, op: op.text, text: lit.text}
+
+	switch c.op {
+	case "=~", "!~":
+		re, err := regexp.Compile(lit.text)
+		if err != nil {
+			return nil, p.errorf(lit, "invalid regex: %v", err)
+		}
+		c.re = re
+		return c, nil
+	case "contains":
+		return c, nil
+	case "glob":
+		if !doublestar.ValidatePattern(filepath.ToSlash(lit.text)) {
+			return nil, p.errorf(lit, "invalid glob %q", lit.text)
+		}
+		return c, nil
+	}
+
+	switch f.kind {
+	case fieldLevel:
+		rank, ok := levelRank(lit.text)
+		if !ok {
+			return nil, p.errorf(lit, "unknown level %q (want DEBUG, INFO, WARN, ERROR or FATAL)", lit.text)
+		}
+		c.level = rank
+		return c, nil
+	case fieldTime:
+		t, err := ParseTime(lit.text, p.now, p.loc)
+		if err != nil {
+			return nil, p.errorf(lit, "%v", err)
+		}
+		c.t, c.isTime = t, true
+		return c, nil
+	}
+
+	if n, err := strconv.ParseFloat(lit.text, 64); err == nil {
+		c.num, c.isNum = n, true
+	} else if t, err := ParseTime(lit.text, p.now, p.loc); err == nil {
+		c.t, c.isTime = t, true // for time-typed fields
+	}
+	return c, nil
+}
+
+// timeLayouts are the absolute time forms accepted in expressions.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseTime reads a time given as a duration before now ("15m", "2h") or as
+// an absolute time ("2026-10-16T09:00"), in loc when it has no offset.
+func ParseTime(s string, now time.Time, loc *time.Location) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: want a duration like 15m or a time like 2026-10-16T09:00", s)
+}
+
+// ---------------------------------------------------------------------------
+// Evaluation
+// ---------------------------------------------------------------------------
+
+type node interface {
+	eval(e *model.LogEntry) bool
+}
+
+type andNode struct{ l, r node }
+type orNode struct{ l, r node }
+type notNode struct{ x node }
+type truthyNode struct{ f field }
+
+func (n andNode) eval(e *model.LogEntry) bool { return n.l.eval(e) && n.r.eval(e) }
+func (n orNode) eval(e *model.LogEntry) bool  { return n.l.eval(e) || n.r.eval(e) }
+func (n notNode) eval(e *model.LogEntry) bool { return !n.x.eval(e) }
+
+func (n truthyNode) eval(e *model.LogEntry) bool {
+	v, ok := n.f.value(e)
+	if !ok {
+		return false
+	}
+	switch v.Kind() {
+	case model.KindNull:
+		return false
+	case model.KindBool:
+		b, _ := v.Bool()
+		return b
+	case model.KindInt, model.KindFloat:
+		f, _ := v.Float()
+		return f != 0
+	default:
+		return v.String() != ""
+	}
+}
+
+type fieldKind int
+
+const (
+	fieldParsed fieldKind = iota // entry.Fields
+	fieldLevel
+	fieldMessage
+	fieldSource
+	fieldRaw
+	fieldTime
+	fieldLate
+	fieldTimeParsed
+)
+
+// field is a reference to an entry attribute or parsed field.
+type field struct {
+	kind fieldKind
+	name string
+}
+
+func newField(name string) field {
+	switch strings.ToLower(name) {
+	case "level":
+		return field{kind: fieldLevel}
+	case "message", "msg":
+		return field{kind: fieldMessage}
+	case "source":
+		return field{kind: fieldSource}
+	case "raw":
+		return field{kind: fieldRaw}
+	case "timestamp", "time":
+		return field{kind: fieldTime}
+	case "late":
+		return field{kind: fieldLate}
+	case "time_parsed":
+		return field{kind: fieldTimeParsed}
+	}
+	return field{kind: fieldParsed, name: strings.TrimPrefix(name, "fields.")}
+}
+
+// value returns the field's value, or false when the entry does not have it.
+func (f field) value(e *model.LogEntry) (model.Value, bool) {
+	switch f.kind {
+	case fieldLevel:
+		return model.StringValue(e.Level), true
+	case fieldMessage:
+		return model.StringValue(e.Message), true
+	case fieldSource:
+		return model.StringValue(e.Source), true
+	case fieldRaw:
+		return model.StringValue(e.Raw), true
+	case fieldTime:
+		return model.TimeValue(e.Timestamp), true
+	case fieldLate:
+		return model.BoolValue(e.Late), true
+	case fieldTimeParsed:
+		return model.BoolValue(e.TimeParsed), true
+	}
+	v, ok := e.Fields[f.name]
+	return v, ok
+}
+
+// compareNode is a comparison of a field against a literal.
+type compareNode struct {
+	f    field
+	op   string
+	text string
+
+	// The literal, read as the field's type where possible.
+	num    float64
+	isNum  bool
+	t      time.Time
+	isTime bool
+	level  int
+	re     *regexp.Regexp
+}
+
+func (c *compareNode) eval(e *model.LogEntry) bool {
+	v, ok := c.f.value(e)
+	if !ok || v.Kind() == model.KindNull {
+		return c.op == "!=" || c.op == "!~"
+	}
+
+	switch c.op {
+	case "=~":
+		return c.re.MatchString(v.String())
+	case "!~":
+		return !c.re.MatchString(v.String())
+	case "contains":
+		return strings.Contains(v.String(), c.text)
+	case "glob":
+		return MatchGlob(c.text, v.String())
+	}
+
+	cmp, ok := c.compare(v)
+	if !ok {
+		return c.op == "!="
+	}
+	switch c.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// compare orders a value against the literal: numerically when both are
+// numbers, by severity for levels, as times for times, else as text.
+// Times cannot be compared with anything but times.
+func (c *compareNode) compare(v model.Value) (int, bool) {
+	if c.f.kind == fieldLevel {
+		rank, ok := levelRank(v.String())
+		if !ok {
+			return 0, false
+		}
+		return compareInts(rank, c.level), true
+	}
+
+	if t, ok := v.Time(); ok {
+		if !c.isTime {
+			return 0, false
+		}
+		return t.Compare(c.t), true
+	}
+
+	if c.isNum {
+		f, ok := v.Float()
+		if !ok && v.Kind() == model.KindString {
+			var err error
+			f, err = strconv.ParseFloat(v.String(), 64)
+			ok = err == nil
+		}
+		if ok {
+			switch {
+			case f < c.num:
+				return -1, true
+			case f > c.num:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+
+	return strings.Compare(v.String(), c.text), true
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// levelRank orders severities, accepting the usual spellings.
+func levelRank(s string) (int, bool) {
+	switch strings.ToUpper(s) {
+	case "DEBUG", "TRACE":
+		return 0, true
+	case "INFO", "NOTICE":
+		return 1, true
+	case "WARN", "WARNING":
+		return 2, true
+	case "ERROR", "ERR":
+		return 3, true
+	case "FATAL", "CRITICAL", "CRIT", "PANIC":
+		return 4, true
+	}
+	return 0, false
+}
+
+// MatchGlob matches a glob against a value. Patterns without a path
+// separator are matched against the file name only.
+func MatchGlob(glob, s string) bool {
+	if !strings.ContainsAny(glob, `/\`) {
+		s = filepath.Base(s)
+	}
+	ok, _ := doublestar.PathMatch(filepath.FromSlash(glob), s)
+	return ok
+}
@@ -0,0 +1,155 @@
+package query
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/model"
+)
+
+func testEntry() model.LogEntry {
+	return model.LogEntry{
+		Timestamp:  time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC),
+		TimeParsed: true,
+		Source:     "/var/log/nginx/access.log",
+		Raw:        `10.0.0.1 "GET /api/orders" 503`,
+		Level:      "ERROR",
+		Message:    "GET /api/orders",
+		Fields: map[string]model.Value{
+			"status":                    model.IntValue(503),
+			"path":                      model.StringValue("/api/orders"),
+			"bytes":                     model.StringValue("1024"), // regex parsers capture text
+			"duration":                  model.FloatValue(0.25),
+			"cached":                    model.BoolValue(false),
+			"http.response.status_code": model.IntValue(503),
+			"started":                   model.TimeValue(time.Date(2026, 10, 16, 9, 29, 0, 0, time.UTC)),
+		},
+	}
+}
+
+func TestMatch(t *testing.T) {
+	entry := testEntry()
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		// Levels compare by severity.
+		{`level>=WARN`, true},
+		{`level >= fatal`, false},
+		{`level == error`, true},
+		{`level < WARNING`, false},
+
+		// Numbers, also when captured as text.
+		{`status>=500`, true},
+		{`status = 503 and bytes > 1000`, true},
+		{`duration < 0.1`, false},
+		{`fields.status != 503`, false},
+		{`http.response.status_code >= 500`, true},
+
+		// Strings, regexes and substrings.
+		{`path =~ "^/api"`, true},
+		{`path !~ '^/api'`, false},
+		{`message contains "orders"`, true},
+		{`not message contains "healthcheck"`, true},
+		{`path == "/api/orders"`, true},
+
+		// Source globs.
+		{`source glob "access.*"`, true},
+		{`source glob "/var/log/**/*.log"`, true},
+		{`source glob "/srv/*.log"`, false},
+
+		// Times.
+		{`timestamp >= "2026-10-16T09:00:00Z"`, true},
+		{`time < 2026-10-16T09:00:00Z`, false},
+		{`started < 2026-10-16T09:30:00Z`, true},
+		{`timestamp > 1h`, false},
+
+		// Bare fields and missing fields.
+		{`cached`, false},
+		{`not cached and time_parsed`, true},
+		{`late`, false},
+		{`user`, false},
+		{`user == "bob"`, false},
+		{`user != "bob"`, true},
+
+		// Boolean logic and precedence.
+		{`level>=WARN and status>=500 and path =~ "^/api" and not message contains "healthcheck"`, true},
+		{`status < 500 or path contains "orders"`, true},
+		{`status < 500 or path contains "users" and level == ERROR`, false},
+		{`(status < 500 or path contains "orders") and level == ERROR`, true},
+		{`not (level == ERROR)`, false},
+		{`LEVEL >= warn AND NOT cached`, true},
+	}
+
+	for _, tt := range tests {
+		expr, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if got := expr.Match(entry); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.expr, tt.want, got)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`level >=`,
+		`level >= LOUD`,
+		`path =~ "("`,
+		`(status > 1`,
+		`status > 1)`,
+		`message contains "unterminated`,
+		`and status > 1`,
+		`timestamp > yesterday`,
+		`status >< 1`,
+	} {
+		_, err := Parse(expr)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("%q: expected a syntax error, got %v", expr, err)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	if got, _ := ParseTime("15m", now, time.UTC); !got.Equal(now.Add(-15 * time.Minute)) {
+		t.Errorf("expected 15m before now, got %v", got)
+	}
+	if got, _ := ParseTime("2026-10-16T09:00:00Z", now, time.UTC); !got.Equal(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected absolute time %v", got)
+	}
+	if _, err := ParseTime("soon", now, time.UTC); err == nil {
+		t.Error("expected an error")
+	}
+
+	// Without an offset, the given zone applies, as for log timestamps.
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+	for _, tt := range []struct {
+		loc  *time.Location
+		want time.Time
+	}{
+		{time.UTC, time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)},
+		{berlin, time.Date(2026, 10, 16, 7, 0, 0, 0, time.UTC)},
+	} {
+		if got, _ := ParseTime("2026-10-16T09:00", now, tt.loc); !got.Equal(tt.want) {
+			t.Errorf("%v: expected %v, got %v", tt.loc, tt.want, got)
+		}
+	}
+	expr, err := ParseIn("timestamp >= 2026-10-16T09:00", berlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expr.Match(model.LogEntry{Timestamp: time.Date(2026, 10, 16, 7, 30, 0, 0, time.UTC)}) {
+		t.Error("expected 09:30 Berlin to match")
+	}
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/model"
+	"github.com/atikulmunna/loom/internal/query"
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// filterSpec is a dashboard filter as sent by the client, either as query
+// parameters on /ws or as a {"type":"filter"} message while connected.
+// Empty parts match everything, except that an empty (not missing) Levels
+// matches nothing, so it keeps its null/[] distinction when echoed back.
+type filterSpec struct {
+	Levels  []string `json:"levels"`            // exact levels, e.g. ["ERROR","WARN"]
+	Sources []string `json:"sources,omitempty"` // globs; without a / they match the file name
+	Text    string   `json:"text,omitempty"`    // case-insensitive substring of message or raw line
+	Where   string   `json:"where,omitempty"`   // expression, as for --where
+}
+
+// filterSpecFromQuery reads a filter from /ws query parameters:
+// levels=ERROR,WARN, source=*.log (repeatable or comma-separated), q=text and
+// where=expression.
+func filterSpecFromQuery(v url.Values) filterSpec {
+	spec := filterSpec{
+		Sources: splitList(v["source"]),
+		Text:    v.Get("q"),
+		Where:   v.Get("where"),
+	}
+	if levels, ok := v["levels"]; ok {
+		spec.Levels = append([]string{}, splitList(levels)...)
+	}
+	return spec
+}
+
+// splitList flattens repeated and comma-separated values.
+func splitList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// entryFilter is a compiled filterSpec. A nil *entryFilter matches everything.
+type entryFilter struct {
+	levels  map[string]bool
+	sources []string
+	text    string
+	where   *query.Expr
+}
+
+// newEntryFilter compiles a filter, rejecting bad globs and expressions.
+// Times without an offset in the expression are read in loc.
+func newEntryFilter(spec filterSpec, loc *time.Location) (*entryFilter, error) {
+	f := &entryFilter{text: strings.ToLower(spec.Text)}
+	if spec.Levels != nil {
+		f.levels = make(map[string]bool, len(spec.Levels))
+		for _, l := range spec.Levels {
+			f.levels[strings.ToUpper(strings.TrimSpace(l))] = true
+		}
+	}
+	for _, s := range spec.Sources {
+		if !doublestar.ValidatePattern(s) {
+			return nil, fmt.Errorf("invalid source glob %q", s)
+		}
+		f.sources = append(f.sources, s)
+	}
+	if strings.TrimSpace(spec.Where) != "" {
+		expr, err := query.ParseIn(spec.Where, loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid where: %w", err)
+		}
+		f.where = expr
+	}
+	return f, nil
+}
+
+// match reports whether an entry passes every part of the filter.
+func (f *entryFilter) match(entry model.LogEntry) bool {
+	if f == nil {
+		return true
+	}
+	if f.levels != nil && !f.levels[entry.Level] {
+		return false
+	}
+	if len(f.sources) > 0 && !f.matchSource(entry.Source) {
+		return false
+	}
+	if f.text != "" &&
+		!strings.Contains(strings.ToLower(entry.Message), f.text) &&
+		!strings.Contains(strings.ToLower(entry.Raw), f.text) {
+		return false
+	}
+	return f.where == nil || f.where.Match(entry)
+}
+
+func (f *entryFilter) matchSource(source string) bool {
+	for _, glob := range f.sources {
+		if query.MatchGlob(glob, source) {
+			return true
+		}
+	}
+	return false
+}
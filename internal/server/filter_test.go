@@ -0,0 +1,91 @@
+package server
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/model"
+)
+
+func TestFilterSpecFromQuery(t *testing.T) {
+	v, _ := url.ParseQuery("levels=ERROR,WARN&source=*.log&source=api/**&q=timeout&where=status>=500")
+	got := filterSpecFromQuery(v)
+	want := filterSpec{
+		Levels:  []string{"ERROR", "WARN"},
+		Sources: []string{"*.log", "api/**"},
+		Text:    "timeout",
+		Where:   "status>=500",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	v, _ = url.ParseQuery("q=x")
+	if got := filterSpecFromQuery(v); got.Levels != nil {
+		t.Errorf("absent levels should be nil, got %#v", got.Levels)
+	}
+	v, _ = url.ParseQuery("levels=")
+	if got := filterSpecFromQuery(v); got.Levels == nil || len(got.Levels) != 0 {
+		t.Errorf("empty levels should be an empty list, got %#v", got.Levels)
+	}
+}
+
+func TestEntryFilter(t *testing.T) {
+	entry := model.LogEntry{
+		Source:  "/var/log/api.log",
+		Level:   "ERROR",
+		Message: "upstream Timeout",
+		Raw:     "ERROR upstream Timeout status=502",
+		Fields:  map[string]model.Value{"status": model.IntValue(502)},
+	}
+
+	tests := []struct {
+		name string
+		spec filterSpec
+		want bool
+	}{
+		{"empty", filterSpec{}, true},
+		{"level", filterSpec{Levels: []string{"warn", "error"}}, true},
+		{"other level", filterSpec{Levels: []string{"INFO"}}, false},
+		{"no levels", filterSpec{Levels: []string{}}, false},
+		{"source name", filterSpec{Sources: []string{"*.txt", "api.*"}}, true},
+		{"source path", filterSpec{Sources: []string{"/var/log/**"}}, true},
+		{"other source", filterSpec{Sources: []string{"db.log"}}, false},
+		{"text", filterSpec{Text: "timeout"}, true},
+		{"text in raw", filterSpec{Text: "status=502"}, true},
+		{"other text", filterSpec{Text: "refused"}, false},
+		{"where", filterSpec{Where: "status >= 500"}, true},
+		{"other where", filterSpec{Where: "status < 500"}, false},
+		{"all", filterSpec{Levels: []string{"ERROR"}, Sources: []string{"*.log"}, Text: "upstream", Where: "status == 502"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newEntryFilter(tt.spec, time.UTC)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := f.match(entry); got != tt.want {
+				t.Errorf("match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var none *entryFilter
+	if !none.match(entry) {
+		t.Error("nil filter should match everything")
+	}
+}
+
+func TestEntryFilterErrors(t *testing.T) {
+	for _, spec := range []filterSpec{
+		{Sources: []string{"[a-"}},
+		{Where: "status >="},
+		{Where: "level > LOUD"},
+	} {
+		if _, err := newEntryFilter(spec, time.UTC); err == nil {
+			t.Errorf("%+v: expected an error", spec)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"io/fs"
 	"net/http"
 	"net/http/pprof"
+	"time"
 
 	"github.com/atikulmunna/loom/internal/aggregator"
 	"github.com/atikulmunna/loom/internal/hub"
@@ -20,6 +21,9 @@ type Server struct {
 	hub        *hub.Hub
 	aggregator *aggregator.Aggregator
 	port       string
+	location   *time.Location // for times without an offset in filters
+
+	suppressedInterval time.Duration
 }
 
 // New creates a web server for the Loom dashboard.
@@ -37,12 +41,21 @@ func New(h *hub.Hub, agg *aggregator.Aggregator, port string) *Server {
 		hub:        h,
 		aggregator: agg,
 		port:       port,
+		location:   time.UTC,
+
+		suppressedInterval: suppressedInterval,
 	}
 
 	s.setupRoutes()
 	return s
 }
 
+// SetLocation sets the zone that times without an offset in dashboard
+// filters are read in, as for log timestamps. It is UTC by default.
+func (s *Server) SetLocation(loc *time.Location) {
+	s.location = loc
+}
+
 // serveEmbedded reads a file from the embedded FS and writes it with the given content type.
 func serveEmbedded(webContent fs.FS, name string, contentType string) gin.HandlerFunc {
 	// Pre-read the file at startup so we don't read on every request.
@@ -82,6 +95,11 @@ func (s *Server) setupRoutes() {
 		c.JSON(http.StatusOK, s.aggregator.Snapshot())
 	})
 
+	// Hub subscribers (CLI, aggregator, one per dashboard connection).
+	s.engine.GET("/api/subscribers", func(c *gin.Context) {
+		c.JSON(http.StatusOK, s.hub.Subscribers())
+	})
+
 	// WebSocket.
 	s.engine.GET("/ws", s.handleWebSocket)
 
@@ -8,11 +8,16 @@
     const MAX_LOG_ENTRIES = 1000;
     const STATS_POLL_INTERVAL = 1000;
     const WS_RECONNECT_DELAY = 2000;
+    const FILTER_INPUT_DELAY = 300;
+    const ALL_LEVELS = ['INFO', 'WARN', 'ERROR', 'FATAL', 'DEBUG'];
 
     // --- State ---
-    const activeFilters = new Set(['INFO', 'WARN', 'ERROR', 'FATAL', 'DEBUG']);
+    const activeFilters = new Set(ALL_LEVELS);
     let ws = null;
     let statsTimer = null;
+    let filterTimer = null;
+    let lastSeq = 0; // resume point after a reconnect
+    let acceptedFilter = {}; // last filter the server acknowledged; used to reconnect
 
     // --- DOM refs ---
     const logContainer = document.getElementById('log-container');
@@ -20,24 +25,60 @@
     const statusDot = document.querySelector('.status-dot');
     const statusText = document.getElementById('status-text');
     const autoscrollCheckbox = document.getElementById('autoscroll');
+    const searchInput = document.getElementById('filter-text');
+    const whereInput = document.getElementById('filter-where');
+    const suppressedEl = document.getElementById('suppressed');
 
     // --- WebSocket ---
     function connectWebSocket() {
         const protocol = location.protocol === 'https:' ? 'wss:' : 'ws:';
-        const url = `${protocol}//${location.host}/ws`;
+        const params = new URLSearchParams();
+        // Only a filter the server has accepted goes in the URL: a rejected
+        // one would be refused before the upgrade, and retried forever.
+        const filter = acceptedFilter;
+        if (filter.levels) params.set('levels', filter.levels.join(','));
+        if (filter.text) params.set('q', filter.text);
+        if (filter.where) params.set('where', filter.where);
+        if (lastSeq > 0) params.set('since', lastSeq);
+        const url = `${protocol}//${location.host}/ws?${params}`;
 
         ws = new WebSocket(url);
 
         ws.onopen = () => {
             setConnectionStatus('connected', 'Connected');
+            if (!sameFilter(currentFilter(), acceptedFilter)) sendFilter();
         };
 
         ws.onmessage = (event) => {
+            let msg;
             try {
-                const entry = JSON.parse(event.data);
-                addLogEntry(entry);
+                msg = JSON.parse(event.data);
             } catch (e) {
-                console.error('Failed to parse log entry:', e);
+                console.error('Failed to parse message:', e);
+                return;
+            }
+            switch (msg.type) {
+                case 'entry':
+                    lastSeq = msg.seq;
+                    addLogEntry(msg);
+                    break;
+                case 'missed':
+                    addGapNotice(msg.count);
+                    break;
+                case 'suppressed':
+                    setSuppressed(msg.count);
+                    break;
+                case 'filter':
+                    acceptedFilter = {
+                        text: msg.text || '',
+                        where: msg.where || '',
+                    };
+                    if (msg.levels) acceptedFilter.levels = msg.levels;
+                    setSuppressed(0);
+                    break;
+                case 'error':
+                    setFilterError(msg.error);
+                    break;
             }
         };
 
@@ -51,6 +92,41 @@
         };
     }
 
+    // currentFilter describes the filter the server applies to new entries.
+    // levels is omitted when every level is selected.
+    function currentFilter() {
+        const filter = {
+            text: searchInput.value.trim(),
+            where: whereInput.value.trim(),
+        };
+        if (activeFilters.size !== ALL_LEVELS.length) {
+            filter.levels = [...activeFilters];
+        }
+        return filter;
+    }
+
+    function sameFilter(a, b) {
+        return (a.text || '') === (b.text || '') &&
+            (a.where || '') === (b.where || '') &&
+            JSON.stringify(a.levels) === JSON.stringify(b.levels);
+    }
+
+    function sendFilter() {
+        setFilterError('');
+        if (ws && ws.readyState === WebSocket.OPEN) {
+            ws.send(JSON.stringify({ type: 'filter', ...currentFilter() }));
+        }
+    }
+
+    function setSuppressed(count) {
+        suppressedEl.textContent = count > 0 ? `${formatNumber(count)} hidden` : '';
+    }
+
+    function setFilterError(message) {
+        whereInput.classList.toggle('filter-input--invalid', message !== '');
+        whereInput.title = message;
+    }
+
     function setConnectionStatus(state, text) {
         statusDot.className = `status-dot status-dot--${state}`;
         statusText.textContent = text;
@@ -72,12 +148,13 @@
 
         const time = formatTime(entry.timestamp);
         const source = entry.source ? entry.source.split(/[/\\]/).pop() : '';
+        const trace = traceLines(entry.raw);
 
         el.innerHTML = `
             <span class="log-entry__time">${time}</span>
             <span class="log-entry__level log-entry__level--${entry.level}">${entry.level.padEnd(5)}</span>
             <span class="log-entry__source" title="${escapeHtml(entry.source)}">${escapeHtml(source)}</span>
-            <span class="log-entry__message">${escapeHtml(entry.message)}</span>
+            <span class="log-entry__message">${escapeHtml(entry.message)}${trace}</span>
         `;
 
         logContainer.appendChild(el);
@@ -94,6 +171,25 @@
         }
     }
 
+    // addGapNotice marks entries this tab never received: lost while
+    // disconnected and no longer kept, or dropped because it fell behind.
+    function addGapNotice(count) {
+        const el = document.createElement('div');
+        el.className = 'log-gap';
+        el.textContent = `${formatNumber(count)} entries missed`;
+        logContainer.appendChild(el);
+    }
+
+    // traceLines renders the continuation lines of a multi-line event.
+    function traceLines(raw) {
+        if (!raw) return '';
+        const nl = raw.indexOf('\n');
+        if (nl < 0) return '';
+        const rest = raw.slice(nl + 1);
+        const count = rest.split('\n').length;
+        return `<details class="log-entry__trace"><summary>${count} more line(s)</summary>${escapeHtml(rest)}</details>`;
+    }
+
     function formatTime(timestamp) {
         try {
             const d = new Date(timestamp);
@@ -114,10 +210,10 @@
     window.toggleFilter = function (level) {
         if (level === 'all') {
             // Toggle all on/off.
-            const allActive = activeFilters.size === 5;
+            const allActive = activeFilters.size === ALL_LEVELS.length;
             activeFilters.clear();
             if (!allActive) {
-                ['INFO', 'WARN', 'ERROR', 'FATAL', 'DEBUG'].forEach(l => activeFilters.add(l));
+                ALL_LEVELS.forEach(l => activeFilters.add(l));
             }
         } else {
             if (activeFilters.has(level)) {
@@ -128,13 +224,22 @@
         }
         updateFilterUI();
         applyFilters();
+        sendFilter();
     };
 
+    // Text and expression filters apply to entries that arrive from now on.
+    function onFilterInput() {
+        clearTimeout(filterTimer);
+        filterTimer = setTimeout(sendFilter, FILTER_INPUT_DELAY);
+    }
+    searchInput.addEventListener('input', onFilterInput);
+    whereInput.addEventListener('input', onFilterInput);
+
     function updateFilterUI() {
         document.querySelectorAll('.filter-btn[data-level]').forEach(btn => {
             const level = btn.dataset.level;
             if (level === 'all') {
-                btn.classList.toggle('filter-btn--active', activeFilters.size === 5);
+                btn.classList.toggle('filter-btn--active', activeFilters.size === ALL_LEVELS.length);
             } else {
                 btn.classList.toggle('filter-btn--active', activeFilters.has(level));
             }
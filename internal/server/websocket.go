@@ -1,10 +1,14 @@
 package server
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/atikulmunna/loom/internal/hub"
 	"github.com/atikulmunna/loom/internal/model"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -16,8 +20,95 @@ var upgrader = websocket.Upgrader{
 	CheckOrigin:     func(r *http.Request) bool { return true },
 }
 
+// suppressedInterval is how often a client is told how many entries its
+// filter has hidden.
+const suppressedInterval = 2 * time.Second
+
+// Messages sent to the client. Every message has a "type": "entry" for a
+// log entry, "suppressed" for the number of entries the current filter has
+// hidden since it was set, "missed" for entries a resuming client asked for
+// that are no longer kept or that were dropped because it fell behind,
+// "filter" echoing an accepted filter update, and
+// "error" for a rejected one.
+type entryMessage struct {
+	Type      string                 `json:"type"`
+	Seq       uint64                 `json:"seq"`
+	Timestamp string                 `json:"timestamp"`
+	Source    string                 `json:"source"`
+	Level     string                 `json:"level"`
+	Message   string                 `json:"message"`
+	Raw       string                 `json:"raw"`
+	Fields    map[string]model.Value `json:"fields,omitempty"`
+}
+
+func newEntryMessage(entry model.LogEntry) entryMessage {
+	return entryMessage{
+		Type:      "entry",
+		Seq:       entry.Seq,
+		Timestamp: entry.Timestamp.Format(time.RFC3339),
+		Source:    entry.Source,
+		Level:     entry.Level,
+		Message:   entry.Message,
+		Raw:       entry.Raw,
+		Fields:    entry.Fields,
+	}
+}
+
+// countMessage is a "suppressed" or "missed" count.
+type countMessage struct {
+	Type  string `json:"type"`
+	Count int64  `json:"count"`
+}
+
+type errorMessage struct {
+	Type  string `json:"type"`
+	Error string `json:"error"`
+}
+
+// filterMessage is sent by the client to replace the connection's filter,
+// and echoed back once the server has accepted it. It is the only message a
+// client sends.
+type filterMessage struct {
+	Type string `json:"type"`
+	filterSpec
+}
+
+// filterUpdate carries a compiled filter (or the reason it was rejected)
+// from the read pump to the write pump.
+type filterUpdate struct {
+	spec   filterSpec
+	filter *entryFilter
+	err    error
+}
+
 // handleWebSocket upgrades to WebSocket and streams log entries to the client.
+// It starts with the kept history after ?since= (all of it for a new client),
+// then follows live. Only entries matching the client's filter are sent; the
+// filter comes from the query string and can be replaced with "filter"
+// messages.
 func (s *Server) handleWebSocket(c *gin.Context) {
+	filter, err := newEntryFilter(filterSpecFromQuery(c.Request.URL.Query()), s.location)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var since uint64
+	if v := c.Query("since"); v != "" {
+		if since, err = strconv.ParseUint(v, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid since %q", v)})
+			return
+		}
+	}
+	// A dashboard that falls behind skips ahead rather than lagging by
+	// default. It may not block the hub: one stalled tab would stall all.
+	policy := hub.DropOldest
+	if v := c.Query("policy"); v != "" {
+		if policy, err = hub.ParsePolicy(v); err != nil || policy == hub.Block {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid policy %q (drop-oldest, drop-newest or sample)", v)})
+			return
+		}
+	}
+
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("websocket upgrade failed: %v", err)
@@ -25,44 +116,107 @@ func (s *Server) handleWebSocket(c *gin.Context) {
 	}
 	defer conn.Close()
 
-	// Subscribe to the hub for log entries.
-	entries := s.hub.Subscribe()
+	// Subscribe to the hub for log entries, and send the backfill first.
+	// The subscription is released when the client goes away.
+	sub, backfill := s.hub.SubscribeSince("ws "+c.Request.RemoteAddr, policy, since)
+	defer sub.Close()
+	entries := sub.Entries()
+	var suppressed, reported int64
+	if backfill.Missed > 0 {
+		if err := conn.WriteJSON(countMessage{Type: "missed", Count: int64(backfill.Missed)}); err != nil {
+			log.Printf("websocket write failed: %v", err)
+			return
+		}
+	}
+	for _, entry := range backfill.Entries {
+		if !filter.match(entry) {
+			suppressed++
+			continue
+		}
+		if err := conn.WriteJSON(newEntryMessage(entry)); err != nil {
+			log.Printf("websocket write failed: %v", err)
+			return
+		}
+	}
 
-	// Read pump — detect client disconnect.
+	// Read pump — receive filter updates and detect client disconnect.
+	updates := make(chan filterUpdate)
+	gone := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
 	go func() {
+		defer close(gone)
 		for {
-			if _, _, err := conn.ReadMessage(); err != nil {
-				conn.Close()
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var msg filterMessage
+			var u filterUpdate
+			if err := json.Unmarshal(data, &msg); err != nil {
+				u.err = fmt.Errorf("invalid message: %w", err)
+			} else if msg.Type == "filter" {
+				u.spec = msg.filterSpec
+				u.filter, u.err = newEntryFilter(msg.filterSpec, s.location)
+			} else {
+				continue
+			}
+			select {
+			case updates <- u:
+			case <-done:
 				return
 			}
 		}
 	}()
 
-	// Write pump — send entries as JSON.
-	for entry := range entries {
-		msg := struct {
-			Timestamp string            `json:"timestamp"`
-			Source    string            `json:"source"`
-			Level    string            `json:"level"`
-			Message  string            `json:"message"`
-			Raw      string            `json:"raw"`
-			Fields   map[string]string `json:"fields,omitempty"`
-		}{
-			Timestamp: entry.Timestamp.Format(time.RFC3339),
-			Source:    entry.Source,
-			Level:    entry.Level,
-			Message:  entry.Message,
-			Raw:      entry.Raw,
-			Fields:   entry.Fields,
+	// Write pump — send matching entries as JSON, and the suppressed count
+	// whenever it has changed. Entries the hub dropped because the client
+	// fell behind (live, or while the backfill was written) show up as a
+	// jump in Seq, and are reported as missed.
+	last := backfill.Last
+	ticker := time.NewTicker(s.suppressedInterval)
+	defer ticker.Stop()
+	for {
+		var out interface{}
+		select {
+		case entry, ok := <-entries:
+			if !ok {
+				return
+			}
+			if entry.Seq > last+1 {
+				if err := conn.WriteJSON(countMessage{Type: "missed", Count: int64(entry.Seq - last - 1)}); err != nil {
+					log.Printf("websocket write failed: %v", err)
+					return
+				}
+			}
+			last = entry.Seq
+			if !filter.match(entry) {
+				suppressed++
+				continue
+			}
+			out = newEntryMessage(entry)
+		case <-ticker.C:
+			if suppressed == reported {
+				continue
+			}
+			reported = suppressed
+			out = countMessage{Type: "suppressed", Count: suppressed}
+		case u := <-updates:
+			if u.err != nil {
+				out = errorMessage{Type: "error", Error: u.err.Error()}
+				break
+			}
+			// The acknowledgement also resets the suppressed count.
+			filter = u.filter
+			suppressed, reported = 0, 0
+			out = filterMessage{Type: "filter", filterSpec: u.spec}
+		case <-gone:
+			return
 		}
 
-		if err := conn.WriteJSON(msg); err != nil {
+		if err := conn.WriteJSON(out); err != nil {
 			log.Printf("websocket write failed: %v", err)
 			return
 		}
 	}
 }
-
-// SubscribeForWS is a helper used by the Hub to create a WebSocket-compatible subscriber.
-// This is unused—Hub.Subscribe() is called directly in handleWebSocket.
-var _ model.LogEntry // ensure model import is used
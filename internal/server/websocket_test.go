@@ -0,0 +1,275 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/hub"
+	"github.com/atikulmunna/loom/internal/model"
+	"github.com/atikulmunna/loom/internal/parser"
+	"github.com/gorilla/websocket"
+)
+
+type wsMessage struct {
+	Type    string `json:"type"`
+	Seq     uint64 `json:"seq"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	Count   int64  `json:"count"`
+	Error   string `json:"error"`
+
+	// Echoed back by a "filter" acknowledgement.
+	Levels []string `json:"levels"`
+	Text   string   `json:"text"`
+}
+
+func TestWebSocketFilter(t *testing.T) {
+	input := make(chan model.RawLine, 10)
+	h := hub.New(input, parser.NewAutoParser(), hub.Options{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.Start(ctx)
+
+	srv := New(h, nil, "")
+	srv.suppressedInterval = 50 * time.Millisecond
+	ts := httptest.NewServer(srv.engine)
+	defer ts.Close()
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws?levels=ERROR"
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	read := func() wsMessage {
+		t.Helper()
+		var m wsMessage
+		if err := conn.ReadJSON(&m); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	// A bad update is rejected and the filter is kept.
+	conn.WriteJSON(map[string]interface{}{"type": "filter", "where": "status >="})
+	if m := read(); m.Type != "error" || m.Error == "" {
+		t.Fatalf("expected an error, got %+v", m)
+	}
+
+	// Narrow the filter; the acknowledgement also shows the handler is
+	// subscribed before any lines are sent.
+	conn.WriteJSON(map[string]interface{}{"type": "filter", "levels": []string{"ERROR", "WARN"}, "text": "disk"})
+	if m := read(); m.Type != "filter" || strings.Join(m.Levels, ",") != "ERROR,WARN" || m.Text != "disk" {
+		t.Fatalf("expected the filter to be acknowledged, got %+v", m)
+	}
+
+	input <- model.RawLine{Text: "INFO disk checked", Source: "app.log"}
+	input <- model.RawLine{Text: "ERROR network down", Source: "app.log"}
+	input <- model.RawLine{Text: "WARN disk almost full", Source: "app.log"}
+
+	if m := read(); m.Type != "entry" || m.Level != "WARN" {
+		t.Fatalf("expected the WARN entry, got %+v", m)
+	}
+	if m := read(); m.Type != "suppressed" || m.Count != 2 {
+		t.Fatalf("expected 2 suppressed, got %+v", m)
+	}
+}
+
+func TestWebSocketBadQuery(t *testing.T) {
+	h := hub.New(make(chan model.RawLine), parser.NewAutoParser(), hub.Options{})
+	ts := httptest.NewServer(New(h, nil, "").engine)
+	defer ts.Close()
+
+	for _, query := range []string{"where=level%3E%3DLOUD", "since=-1", "policy=block", "policy=fast"} {
+		resp, err := http.Get(ts.URL + "/ws?" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, resp.StatusCode)
+		}
+	}
+}
+
+func TestWebSocketBackfill(t *testing.T) {
+	input := make(chan model.RawLine, 10)
+	h := hub.New(input, parser.NewAutoParser(), hub.Options{History: 2})
+	probe := h.Subscribe("probe", hub.Block).Entries()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.Start(ctx)
+
+	for _, line := range []string{"INFO one", "ERROR two", "INFO three"} {
+		input <- model.RawLine{Text: line, Source: "app.log"}
+		<-probe
+	}
+
+	srv := New(h, nil, "")
+	srv.suppressedInterval = 50 * time.Millisecond
+	ts := httptest.NewServer(srv.engine)
+	defer ts.Close()
+	base := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+
+	dial := func(query string) func() wsMessage {
+		conn, _, err := websocket.DefaultDialer.Dial(base+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		return func() wsMessage {
+			t.Helper()
+			var m wsMessage
+			if err := conn.ReadJSON(&m); err != nil {
+				t.Fatal(err)
+			}
+			return m
+		}
+	}
+
+	// A new client gets the kept history, through its filter.
+	read := dial("?levels=ERROR")
+	if m := read(); m.Type != "entry" || m.Seq != 2 {
+		t.Fatalf("expected backfilled seq 2, got %+v", m)
+	}
+	if m := read(); m.Type != "suppressed" || m.Count != 1 {
+		t.Fatalf("expected 1 suppressed, got %+v", m)
+	}
+
+	// A client resuming after seq 2 gets exactly what followed.
+	read = dial("?since=2")
+	if m := read(); m.Type != "entry" || m.Seq != 3 {
+		t.Fatalf("expected seq 3, got %+v", m)
+	}
+	input <- model.RawLine{Text: "WARN four", Source: "app.log"}
+	if m := read(); m.Type != "entry" || m.Seq != 4 {
+		t.Fatalf("expected live seq 4, got %+v", m)
+	}
+
+	// The history now holds 3 and 4, so a client resuming after 1 hears
+	// that it missed seq 2.
+	read = dial("?since=1")
+	if m := read(); m.Type != "missed" || m.Count != 1 {
+		t.Fatalf("expected 1 missed, got %+v", m)
+	}
+	if m := read(); m.Type != "entry" || m.Seq != 3 {
+		t.Fatalf("expected seq 3, got %+v", m)
+	}
+}
+
+func TestWebSocketReleasesSubscription(t *testing.T) {
+	h := hub.New(make(chan model.RawLine), parser.NewAutoParser(), hub.Options{})
+	ts := httptest.NewServer(New(h, nil, "").engine)
+	defer ts.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor := func(n int) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for len(h.Subscribers()) != n {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d subscribers, got %+v", n, h.Subscribers())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitFor(1)
+	if sub := h.Subscribers()[0]; !strings.HasPrefix(sub.Name, "ws ") || sub.Policy != "drop-oldest" {
+		t.Errorf("unexpected subscriber %+v", sub)
+	}
+
+	conn.Close()
+	waitFor(0)
+}
+
+func TestWebSocketReportsDroppedEntries(t *testing.T) {
+	const lines = 2000
+	input := make(chan model.RawLine, lines+1)
+	h := hub.New(input, parser.NewAutoParser(), hub.Options{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.Start(ctx)
+
+	// Small socket buffers fill quickly.
+	ts := httptest.NewUnstartedServer(New(h, nil, "").engine)
+	ts.Listener = smallBufferListener{ts.Listener}
+	ts.Start()
+	defer ts.Close()
+	dialer := websocket.Dialer{NetDial: func(network, addr string) (net.Conn, error) {
+		c, err := net.Dial(network, addr)
+		if err == nil {
+			c.(*net.TCPConn).SetReadBuffer(4 << 10)
+		}
+		return c, err
+	}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Stop reading until the socket buffers and the subscription's channel
+	// are full and the hub has dropped entries for this client.
+	waitFor := func(ok func([]hub.SubscriberStats) bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !ok(h.Subscribers()) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out, subscribers %+v", h.Subscribers())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitFor(func(s []hub.SubscriberStats) bool { return len(s) == 1 })
+	for i := 0; i < lines; i++ {
+		input <- model.RawLine{Text: "INFO line", Source: "app.log"}
+	}
+	waitFor(func(s []hub.SubscriberStats) bool { return s[0].Dropped > 0 })
+	input <- model.RawLine{Text: "WARN last", Source: "app.log"}
+
+	// Every entry is either received or counted as missed.
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	var received, missed int64
+	for {
+		var m wsMessage
+		if err := conn.ReadJSON(&m); err != nil {
+			t.Fatal(err)
+		}
+		switch m.Type {
+		case "entry":
+			received++
+		case "missed":
+			missed += m.Count
+		}
+		if m.Level == "WARN" {
+			break
+		}
+	}
+	if missed == 0 || received+missed != lines+1 {
+		t.Errorf("received %d and missed %d of %d entries", received, missed, lines+1)
+	}
+}
+
+// smallBufferListener shrinks the send buffer of accepted connections.
+type smallBufferListener struct {
+	net.Listener
+}
+
+func (l smallBufferListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err == nil {
+		c.(*net.TCPConn).SetWriteBuffer(4 << 10)
+	}
+	return c, err
+}
@@ -7,11 +7,33 @@ import (
 )
 
 // checkpointData is the on-disk JSON structure for persisted offsets.
+//
+// Older versions stored only {"offsets": {path: offset}}; those entries are
+// migrated into Files on load and are trusted until the file is next read.
 type checkpointData struct {
-	Offsets map[string]int64 `json:"offsets"`
+	Files   map[string]FileState `json:"files"`
+	Offsets map[string]int64     `json:"offsets,omitempty"`
+}
+
+// FileState is the saved read position of one file, together with enough
+// identity to tell whether the file at that path is still the same file.
+type FileState struct {
+	Offset int64 `json:"offset"`
+
+	// Device and Inode identify the file on Unix systems (zero elsewhere).
+	Device uint64 `json:"device,omitempty"`
+	Inode  uint64 `json:"inode,omitempty"`
+
+	// Fingerprint is a hash of the first FingerprintLen bytes of the file.
+	Fingerprint    string `json:"fingerprint,omitempty"`
+	FingerprintLen int64  `json:"fingerprint_len,omitempty"`
+
+	// Done marks a compressed file that has been read completely.
+	Done bool `json:"done,omitempty"`
 }
 
 // Checkpoint persists file read offsets so tailing can resume after a restart.
+// A nil *Checkpoint is valid: it remembers nothing and saves nothing.
 type Checkpoint struct {
 	mu   sync.RWMutex
 	path string
@@ -22,7 +44,7 @@ type Checkpoint struct {
 func NewCheckpoint(path string) (*Checkpoint, error) {
 	c := &Checkpoint{
 		path: path,
-		data: checkpointData{Offsets: make(map[string]int64)},
+		data: checkpointData{Files: make(map[string]FileState)},
 	}
 
 	// Try to load existing checkpoint.
@@ -30,30 +52,70 @@ func NewCheckpoint(path string) (*Checkpoint, error) {
 	if err == nil {
 		_ = json.Unmarshal(raw, &c.data)
 	}
-	if c.data.Offsets == nil {
-		c.data.Offsets = make(map[string]int64)
+	if c.data.Files == nil {
+		c.data.Files = make(map[string]FileState)
 	}
 
+	// Migrate the old offsets-only format.
+	for p, offset := range c.data.Offsets {
+		if _, ok := c.data.Files[p]; !ok {
+			c.data.Files[p] = FileState{Offset: offset}
+		}
+	}
+	c.data.Offsets = nil
+
 	return c, nil
 }
 
 // Get returns the saved offset for a file path.
 func (c *Checkpoint) Get(path string) (int64, bool) {
+	if c == nil {
+		return 0, false
+	}
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	v, ok := c.data.Offsets[path]
-	return v, ok
+	v, ok := c.data.Files[path]
+	return v.Offset, ok
 }
 
-// Set records the current offset for a file path.
+// Set records the current offset for a file path, keeping its saved identity.
 func (c *Checkpoint) Set(path string, offset int64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := c.data.Files[path]
+	st.Offset = offset
+	c.data.Files[path] = st
+}
+
+// State returns the saved offset and identity for a file path.
+func (c *Checkpoint) State(path string) (FileState, bool) {
+	if c == nil {
+		return FileState{}, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	st, ok := c.data.Files[path]
+	return st, ok
+}
+
+// SetState records the offset and identity for a file path.
+func (c *Checkpoint) SetState(path string, st FileState) {
+	if c == nil {
+		return
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.data.Offsets[path] = offset
+	c.data.Files[path] = st
 }
 
 // Save writes the checkpoint data to disk atomically.
 func (c *Checkpoint) Save() error {
+	if c == nil {
+		return nil
+	}
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
@@ -0,0 +1,211 @@
+package tailer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/model"
+	"github.com/klauspost/compress/zstd"
+)
+
+// archiveSettle is how long a compressed file must go unmodified before it
+// is read, so archives still being written by logrotate are not read early.
+const archiveSettle = time.Second
+
+// keepRotated is how many closed rotated-away files are remembered, so
+// that their archives are recognised when logrotate compresses them later
+// (with delaycompress, one rotation later).
+const keepRotated = 16
+
+// Magic bytes of the supported compression formats.
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// compressionOf returns the compression format of a file ("gzip", "bzip2"
+// or "zstd") detected from its magic bytes, or "" for a plain file.
+func compressionOf(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	magic := make([]byte, 4)
+	n, _ := f.ReadAt(magic, 0)
+	magic = magic[:n]
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return "gzip"
+	case bytes.HasPrefix(magic, bzip2Magic):
+		return "bzip2"
+	case bytes.HasPrefix(magic, zstdMagic):
+		return "zstd"
+	}
+	return ""
+}
+
+// looksCompressed reports whether path is an archive, or an empty file with
+// an archive extension that is about to become one.
+func looksCompressed(path string) bool {
+	if compressionOf(path) != "" {
+		return true
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > 0 {
+		return false
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gz", ".bz2", ".zst":
+		return true
+	}
+	return false
+}
+
+// decompress wraps r in a reader for the given compression format.
+func decompress(r io.Reader, kind string) (io.ReadCloser, error) {
+	switch kind {
+	case "gzip":
+		return gzip.NewReader(r)
+	case "bzip2":
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	default:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	}
+}
+
+// readCompressed streams the lines of a compressed file in one pass, from
+// the start position in Options when useStart is set. Archives do not grow,
+// so instead of an offset the checkpoint records that the file is done; a
+// done archive is skipped unless it was replaced or a start position is set.
+func (t *Tailer) readCompressed(path, kind string, useStart bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Printf("cannot open %s: %v", path, err)
+		return
+	}
+	defer f.Close()
+
+	useStart = useStart && t.opts.hasStart()
+	if saved, ok := t.ckpt.State(path); ok && saved.Done && sameFile(f, saved) && !useStart {
+		return
+	}
+
+	r, err := decompress(f, kind)
+	if err != nil {
+		log.Printf("cannot read %s archive %s: %v", kind, path, err)
+		return
+	}
+	defer r.Close()
+
+	var lines io.Reader = r
+	if useStart {
+		lines = t.archiveStart(r, path)
+	}
+	scanner := bufio.NewScanner(lines)
+	for scanner.Scan() {
+		if t.pastUntil(scanner.Text(), path) {
+			break
+		}
+		t.out <- model.RawLine{Text: scanner.Text(), Source: path}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("read error on %s: %v", path, err)
+		return
+	}
+	t.markDone(path, f)
+}
+
+// markDone records in the checkpoint that an archive has been read.
+func (t *Tailer) markDone(path string, f *os.File) {
+	size, _ := f.Seek(0, io.SeekEnd)
+	st := identify(f, size)
+	st.Done = true
+	t.ckpt.SetState(path, st)
+}
+
+// readSettledArchives reads archives that appeared while running once they
+// have stopped changing. An archive of a file that was tailed, as when
+// logrotate compresses app.log.1, holds lines that were already sent: it is
+// marked done without reading it again.
+func (t *Tailer) readSettledArchives() {
+	now := time.Now()
+	for path, changed := range t.archives {
+		if now.Sub(changed) < archiveSettle {
+			continue
+		}
+		delete(t.archives, path)
+		kind := compressionOf(path)
+		if kind == "" {
+			continue
+		}
+		if from, ok := t.archivedFrom(path, kind); ok {
+			log.Printf("%s is an archive of %s, which was already read", path, from)
+			if f, err := os.Open(path); err == nil {
+				t.markDone(path, f)
+				f.Close()
+			}
+			continue
+		}
+		t.readCompressed(path, kind, false)
+	}
+}
+
+// archivedFrom returns the tailed file whose content an archive holds,
+// matching the archive's first bytes against the fingerprint of each open,
+// draining and recently rotated file.
+func (t *Tailer) archivedFrom(path, kind string) (string, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	r, err := decompress(f, kind)
+	if err != nil {
+		return "", false
+	}
+	defer r.Close()
+	head := make([]byte, fingerprintSize)
+	n, _ := io.ReadFull(r, head)
+	head = head[:n]
+
+	t.mu.Lock()
+	known := make([]*trackedFile, 0, len(t.files)+len(t.draining)+len(t.rotated))
+	for _, tf := range t.files {
+		known = append(known, tf)
+	}
+	t.mu.Unlock()
+	for _, tf := range t.draining {
+		known = append(known, tf)
+	}
+	known = append(known, t.rotated...)
+
+	for _, tf := range known {
+		l := tf.id.FingerprintLen
+		if l == 0 || l > int64(len(head)) {
+			continue
+		}
+		sum := sha256.Sum256(head[:l])
+		if hex.EncodeToString(sum[:]) == tf.id.Fingerprint {
+			return tf.path, true
+		}
+	}
+	return "", false
+}
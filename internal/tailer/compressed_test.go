@@ -0,0 +1,253 @@
+package tailer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/watcher"
+	"github.com/klauspost/compress/zstd"
+)
+
+// bz2Lines is "bz line one\nbz line two\n" compressed with bzip2; the
+// standard library can only decompress that format.
+const bz2Lines = "425a6839314159265359c9a6f44d0000035180001040001225849020002129304f53d4201a69a3b6ce1428469a874367c5dc914e14243269bd1340"
+
+func writeArchives(t *testing.T, dir string) {
+	t.Helper()
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte("gz line one\ngz line two\n"))
+	zw.Close()
+
+	var zst bytes.Buffer
+	enc, _ := zstd.NewWriter(&zst)
+	_, _ = enc.Write([]byte("zst line one\nzst line two\n"))
+	enc.Close()
+
+	bz, _ := hex.DecodeString(bz2Lines)
+
+	// Extensions are deliberately misleading: detection uses magic bytes.
+	for name, data := range map[string][]byte{
+		"app.log.1.gz": gz.Bytes(),
+		"app.log.2":    zst.Bytes(),
+		"app.log.3.z":  bz,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readArchives runs a tailer over dir until it has been quiet for a while
+// and returns the lines it emitted.
+func readArchives(t *testing.T, dir string, ckpt *Checkpoint) []string {
+	t.Helper()
+
+	w, err := watcher.New([]string{filepath.Join(dir, "app.log*")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tail := New(w, ckpt, Options{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go w.Start(ctx)
+	go tail.Start(ctx)
+
+	var lines []string
+	for {
+		select {
+		case raw := <-tail.Lines():
+			lines = append(lines, raw.Text)
+		case <-time.After(500 * time.Millisecond):
+			// Allow goroutines to stop before TempDir cleanup.
+			cancel()
+			time.Sleep(200 * time.Millisecond)
+			sort.Strings(lines)
+			return lines
+		}
+	}
+}
+
+func TestReadCompressedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeArchives(t, dir)
+
+	ckpt, err := NewCheckpoint(filepath.Join(dir, ".loom-state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := readArchives(t, dir, ckpt)
+	want := []string{"bz line one", "bz line two", "gz line one", "gz line two", "zst line one", "zst line two"}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %v, got %v", want, lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d: expected %q, got %q", i, want[i], lines[i])
+		}
+	}
+
+	if st, ok := ckpt.State(filepath.Join(dir, "app.log.1.gz")); !ok || !st.Done {
+		t.Errorf("expected archive to be checkpointed as done, got %+v", st)
+	}
+
+	// A second run skips archives that are already done.
+	if lines := readArchives(t, dir, ckpt); len(lines) != 0 {
+		t.Errorf("expected done archives to be skipped, got %v", lines)
+	}
+}
+
+func gzipped(t *testing.T, text string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write([]byte(text))
+	zw.Close()
+	return buf.Bytes()
+}
+
+// An archive of a file that was tailed is not read again; an unrelated one
+// that appears while running is.
+func TestSkipArchiveOfTailedFile(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(logPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := watcher.New([]string{filepath.Join(dir, "app.log*")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ckpt, err := NewCheckpoint(filepath.Join(dir, ".loom-state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tail := New(w, ckpt, Options{RotateGrace: 500 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		time.Sleep(200 * time.Millisecond)
+	}()
+	go w.Start(ctx)
+	go tail.Start(ctx)
+	time.Sleep(300 * time.Millisecond)
+
+	_ = os.WriteFile(logPath, []byte("line1\nline2\n"), 0644)
+	for _, want := range []string{"line1", "line2"} {
+		select {
+		case raw := <-tail.Lines():
+			if raw.Text != want {
+				t.Fatalf("expected %q, got %q", want, raw.Text)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+
+	// Compress and remove it, as logrotate does.
+	archive := filepath.Join(dir, "app.log.1.gz")
+	_ = os.WriteFile(archive, gzipped(t, "line1\nline2\n"), 0644)
+	_ = os.Remove(logPath)
+	_ = os.WriteFile(filepath.Join(dir, "app.log.2.gz"), gzipped(t, "older\n"), 0644)
+
+	var got []string
+	timeout := time.After(3 * time.Second)
+	for done := false; !done; {
+		select {
+		case raw := <-tail.Lines():
+			got = append(got, raw.Text)
+		case <-timeout:
+			done = true
+		}
+	}
+	if len(got) != 1 || got[0] != "older" {
+		t.Errorf("expected only the unrelated archive, got %v", got)
+	}
+	if st, ok := ckpt.State(archive); !ok || !st.Done {
+		t.Errorf("expected the archive to be checkpointed as done, got %+v", st)
+	}
+}
+
+// clockStamp reads a leading "15:04" timestamp.
+func clockStamp(line, source string) (time.Time, bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return time.Time{}, false
+	}
+	ts, err := time.Parse("15:04", fields[0])
+	return ts, err == nil
+}
+
+func TestArchiveStart(t *testing.T) {
+	at := func(hour, min int) time.Time { return time.Date(0, 1, 1, hour, min, 0, 0, time.UTC) }
+	tests := []struct {
+		name    string
+		content string
+		opts    Options
+		want    string
+	}{
+		{"tail", "a\nb\nc\nd\n", Options{TailLines: 2}, "c\nd\n"},
+		{"tail more than there is", "a\nb\n", Options{TailLines: 5}, "a\nb\n"},
+		{"tail without final newline", "a\nb\nc", Options{TailLines: 2}, "b\nc"},
+		{"since", "09:00 a\n  trace\n09:30 b\n  trace\n10:00 c\n", Options{Since: at(9, 30)}, "09:30 b\n  trace\n10:00 c\n"},
+		{"since keeps leading lines", "header\n09:30 b\n", Options{Since: at(9, 0)}, "header\n09:30 b\n"},
+		{"since drops leading lines of old entries", "header\n08:00 a\n09:30 b\n", Options{Since: at(9, 0)}, "09:30 b\n"},
+		{"since after everything", "09:00 a\n  trace\n", Options{Since: at(10, 0)}, ""},
+		{"since without timestamps", "x\ny\n", Options{Since: at(10, 0)}, "x\ny\n"},
+		{"no start", "a\nb\n", Options{}, "a\nb\n"},
+	}
+	for _, tt := range tests {
+		tt.opts.Timestamp = clockStamp
+		tail := &Tailer{opts: tt.opts}
+		got, err := io.ReadAll(tail.archiveStart(strings.NewReader(tt.content), "app.log.1.gz"))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+// --since applies to archives, merged or not.
+func TestNoFollowArchiveSince(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "app.log.1.gz"), gzipped(t, "09:00 old\n  trace\n09:30 kept\n"), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "app.log"), []byte("09:10 old\n09:40 new\n"), 0644)
+
+	for _, merge := range []bool{false, true} {
+		w, err := watcher.New([]string{filepath.Join(dir, "app.log*")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tail := New(w, nil, Options{
+			Since:     time.Date(0, 1, 1, 9, 20, 0, 0, time.UTC),
+			NoFollow:  true,
+			Merge:     merge,
+			Timestamp: clockStamp,
+		})
+		go tail.Start(context.Background())
+
+		var got []string
+		for raw := range tail.Lines() {
+			got = append(got, raw.Text)
+		}
+		sort.Strings(got)
+		if want := "09:30 kept|09:40 new"; strings.Join(got, "|") != want {
+			t.Errorf("merge %v: expected %s, got %s", merge, want, strings.Join(got, "|"))
+		}
+	}
+}
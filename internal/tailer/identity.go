@@ -0,0 +1,51 @@
+package tailer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+)
+
+// fingerprintSize is how many leading bytes of a file are hashed to
+// recognize it after a restart or rotation.
+const fingerprintSize = 1024
+
+// identify returns the identity of an open file with the given offset.
+func identify(f *os.File, offset int64) FileState {
+	st := FileState{Offset: offset}
+	if info, err := f.Stat(); err == nil {
+		st.Device, st.Inode, _ = fileID(info)
+	}
+	st.Fingerprint, st.FingerprintLen = fingerprint(f, fingerprintSize)
+	return st
+}
+
+// fingerprint hashes up to n leading bytes of f without moving its read
+// position, returning the hash and the number of bytes hashed.
+func fingerprint(f *os.File, n int64) (string, int64) {
+	buf := make([]byte, n)
+	read, _ := f.ReadAt(buf, 0)
+	sum := sha256.Sum256(buf[:read])
+	return hex.EncodeToString(sum[:]), int64(read)
+}
+
+// sameFile reports whether the open file f is the one described by saved.
+// Entries without identity (migrated from the old format) are trusted.
+func sameFile(f *os.File, saved FileState) bool {
+	if saved.Inode != 0 {
+		info, err := f.Stat()
+		if err != nil {
+			return false
+		}
+		if dev, ino, ok := fileID(info); ok && (dev != saved.Device || ino != saved.Inode) {
+			return false
+		}
+	}
+	if saved.Fingerprint != "" {
+		fp, n := fingerprint(f, saved.FingerprintLen)
+		if n != saved.FingerprintLen || fp != saved.Fingerprint {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package tailer
+
+import "os"
+
+// fileID is unavailable on this platform; files are identified by their
+// fingerprint alone.
+func fileID(info os.FileInfo) (dev, ino uint64, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,144 @@
+package tailer
+
+import (
+	"bufio"
+	"container/heap"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/atikulmunna/loom/internal/model"
+)
+
+// cursor is one file in a k-way merge, positioned on its next line.
+type cursor struct {
+	path   string
+	r      *bufio.Reader
+	closer io.Closer
+	index  int // keeps file order for equal timestamps
+
+	line  string
+	ts    time.Time
+	hasTS bool
+}
+
+// mergeFiles reads files to EOF in timestamp order across all of them (a
+// k-way merge), for bulk reads with Options.Merge. Lines without a
+// timestamp stay with the line before them.
+func (t *Tailer) mergeFiles(paths []string) {
+	var h cursorHeap
+	for i, p := range paths {
+		c := t.newCursor(p, i)
+		if c == nil {
+			continue
+		}
+		if t.advance(c) {
+			h = append(h, c)
+		} else {
+			c.closer.Close()
+		}
+	}
+	heap.Init(&h)
+
+	for h.Len() > 0 {
+		c := heap.Pop(&h).(*cursor)
+		t.out <- model.RawLine{Text: c.line, Source: c.path}
+
+		for {
+			if !t.advance(c) {
+				c.closer.Close()
+				break
+			}
+			if !c.hasTS {
+				t.out <- model.RawLine{Text: c.line, Source: c.path}
+				continue
+			}
+			heap.Push(&h, c)
+			break
+		}
+	}
+}
+
+// newCursor opens a file for merging: archives are decompressed, plain
+// files start at the position openFile chooses.
+func (t *Tailer) newCursor(path string, index int) *cursor {
+	c := &cursor{path: path, index: index}
+
+	if kind := compressionOf(path); kind != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Printf("cannot open %s: %v", path, err)
+			return nil
+		}
+		r, err := decompress(f, kind)
+		if err != nil {
+			log.Printf("cannot read %s archive %s: %v", kind, path, err)
+			f.Close()
+			return nil
+		}
+		c.r, c.closer = bufio.NewReader(t.archiveStart(r, path)), multiCloser{r, f}
+		return c
+	}
+
+	t.openFile(path, false)
+	t.mu.Lock()
+	tf, ok := t.files[path]
+	delete(t.files, path)
+	t.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	c.r, c.closer = bufio.NewReader(tf.file), tf.file
+	return c
+}
+
+// advance moves a cursor to its next line. It reports false at EOF or at
+// the first line stamped after Options.Until.
+func (t *Tailer) advance(c *cursor) bool {
+	line, err := c.r.ReadString('\n')
+	if line == "" && err != nil {
+		if err != io.EOF {
+			log.Printf("read error on %s: %v", c.path, err)
+		}
+		return false
+	}
+	c.line = strings.TrimRight(line, "\r\n")
+
+	c.hasTS = false
+	if t.opts.Timestamp != nil {
+		c.ts, c.hasTS = t.opts.Timestamp(c.line, c.path)
+	}
+	return !(c.hasTS && !t.opts.Until.IsZero() && c.ts.After(t.opts.Until))
+}
+
+// multiCloser closes a decompressor and the file below it.
+type multiCloser []io.Closer
+
+func (m multiCloser) Close() error {
+	for _, c := range m {
+		c.Close()
+	}
+	return nil
+}
+
+// cursorHeap is a min-heap of cursors by the timestamp of their next line.
+type cursorHeap []*cursor
+
+func (h cursorHeap) Len() int { return len(h) }
+func (h cursorHeap) Less(i, j int) bool {
+	if !h[i].ts.Equal(h[j].ts) {
+		return h[i].ts.Before(h[j].ts)
+	}
+	return h[i].index < h[j].index
+}
+func (h cursorHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *cursorHeap) Push(x any)   { *h = append(*h, x.(*cursor)) }
+func (h *cursorHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
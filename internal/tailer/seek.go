@@ -0,0 +1,230 @@
+package tailer
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// seekBlock is the chunk size used when scanning a file backwards.
+const seekBlock = 4096
+
+// startOffset returns where to begin reading a file that was present at
+// startup when a start position (--from-beginning, --tail, --since) is set.
+func (t *Tailer) startOffset(f *os.File) int64 {
+	info, err := f.Stat()
+	if err != nil {
+		return 0
+	}
+	switch {
+	case t.opts.TailLines > 0:
+		return tailOffset(f, info.Size(), t.opts.TailLines)
+	case !t.opts.Since.IsZero() && t.opts.Timestamp != nil:
+		return sinceOffset(f, info.Size(), t.opts.Since, t.opts.Timestamp)
+	default:
+		return 0
+	}
+}
+
+// tailOffset returns the offset of the n-th last line of a file, like tail -n.
+func tailOffset(f *os.File, size int64, n int) int64 {
+	buf := make([]byte, seekBlock)
+	pos := size
+	newlines := 0
+
+	// A newline ending the last line does not start another one.
+	if size > 0 {
+		if _, err := f.ReadAt(buf[:1], size-1); err == nil && buf[0] == '\n' {
+			pos--
+		}
+	}
+
+	for pos > 0 {
+		chunk := int64(seekBlock)
+		if pos < chunk {
+			chunk = pos
+		}
+		pos -= chunk
+		if _, err := f.ReadAt(buf[:chunk], pos); err != nil && err != io.EOF {
+			return 0
+		}
+		for i := chunk - 1; i >= 0; i-- {
+			if buf[i] == '\n' {
+				newlines++
+				if newlines == n {
+					return pos + i + 1
+				}
+			}
+		}
+	}
+	return 0
+}
+
+// sinceOffset binary-searches a file for the first line whose timestamp is
+// not before since. Lines without a timestamp (stack traces, blank lines)
+// stay with the line before them.
+func sinceOffset(f *os.File, size int64, since time.Time, timestamp func(line, source string) (time.Time, bool)) int64 {
+	lo, hi := int64(0), size // both always at the start of a line (or EOF)
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start := lineStart(f, mid)
+		if start >= hi {
+			start = lo // no line starts between mid and hi
+		}
+
+		ts, at, end, ok := firstTimestamp(f, start, hi, timestamp)
+		if !ok && start > lo {
+			// Only continuation lines from start to hi: look from lo for
+			// the entry they belong to.
+			ts, at, end, ok = firstTimestamp(f, lo, start, timestamp)
+		}
+		switch {
+		case !ok && lo == 0:
+			hi = lo // leading lines without any timestamp are kept
+		case !ok:
+			lo = hi // they continue the entry before lo, which is too old
+		case !ts.Before(since):
+			hi = at
+		default:
+			lo = end
+		}
+	}
+	return lo
+}
+
+// lineStart returns the offset of the first line starting at or after pos.
+func lineStart(f *os.File, pos int64) int64 {
+	if pos == 0 {
+		return 0
+	}
+	buf := make([]byte, seekBlock)
+	pos-- // the byte before pos may be the newline ending the previous line
+	for {
+		n, err := f.ReadAt(buf, pos)
+		if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+			return pos + int64(i) + 1
+		}
+		if err != nil {
+			return pos + int64(n)
+		}
+		pos += int64(n)
+	}
+}
+
+// firstTimestamp reads lines from start up to limit and returns the first
+// timestamp found, with the offsets where that line starts and ends.
+func firstTimestamp(f *os.File, start, limit int64, timestamp func(line, source string) (time.Time, bool)) (ts time.Time, at, end int64, ok bool) {
+	r := bufio.NewReader(io.NewSectionReader(f, start, limit-start))
+	pos := start
+	for {
+		line, err := r.ReadString('\n')
+		at, pos = pos, pos+int64(len(line))
+		if ts, ok := timestamp(strings.TrimRight(line, "\r\n"), f.Name()); ok {
+			return ts, at, pos, true
+		}
+		if err != nil {
+			return time.Time{}, pos, pos, false
+		}
+	}
+}
+
+// archiveStart applies the start position in Options to a decompressed
+// archive, which cannot be seeked: the last TailLines lines are kept in a
+// ring, and lines before Since are skipped as sinceOffset would skip them.
+func (t *Tailer) archiveStart(r io.Reader, source string) io.Reader {
+	switch {
+	case t.opts.TailLines > 0:
+		return tailLines(r, t.opts.TailLines)
+	case !t.opts.Since.IsZero() && t.opts.Timestamp != nil:
+		return &sinceReader{
+			r:         bufio.NewReader(r),
+			since:     t.opts.Since,
+			timestamp: t.opts.Timestamp,
+			source:    source,
+		}
+	default:
+		return r
+	}
+}
+
+// tailLines reads r to the end and returns its last n lines.
+func tailLines(r io.Reader, n int) io.Reader {
+	ring := make([]string, n)
+	next, count := 0, 0
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			ring[next] = line
+			next = (next + 1) % n
+			count++
+		}
+		if err != nil {
+			break
+		}
+	}
+	if count < n {
+		return strings.NewReader(strings.Join(ring[:count], ""))
+	}
+	return strings.NewReader(strings.Join(ring[next:], "") + strings.Join(ring[:next], ""))
+}
+
+// sinceReader passes on a stream from its first line not stamped before
+// since. Lines without a timestamp stay with the line before them; leading
+// ones are kept with the first line that is kept, or when no line has a
+// timestamp at all.
+type sinceReader struct {
+	r         *bufio.Reader
+	since     time.Time
+	timestamp func(line, source string) (time.Time, bool)
+	source    string
+
+	buf     []byte // lines to pass on
+	pending []byte // leading unstamped lines, waiting for a stamped one
+	found   bool   // reached since; the rest passes through
+	stamped bool   // seen a timestamp
+}
+
+func (s *sinceReader) Read(p []byte) (int, error) {
+	for len(s.buf) == 0 {
+		if s.found {
+			return s.r.Read(p)
+		}
+		line, err := s.r.ReadString('\n')
+		if line != "" {
+			s.skip(line)
+		}
+		if err != nil {
+			if !s.stamped {
+				s.buf, s.pending, s.stamped = s.pending, nil, true
+			}
+			if len(s.buf) == 0 {
+				return 0, err
+			}
+		}
+	}
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	return n, nil
+}
+
+// skip looks at one line before since has been reached.
+func (s *sinceReader) skip(line string) {
+	ts, ok := s.timestamp(strings.TrimRight(line, "\r\n"), s.source)
+	switch {
+	case !ok:
+		if !s.stamped {
+			s.pending = append(s.pending, line...)
+		}
+	case ts.Before(s.since):
+		s.stamped = true
+		s.pending = nil
+	default:
+		s.found, s.stamped = true, true
+		s.buf = append(s.pending, line...)
+		s.pending = nil
+	}
+}
@@ -0,0 +1,97 @@
+package tailer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTemp(t *testing.T, content string) *os.File {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "seek.log")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+// rest returns the file content from offset on.
+func rest(t *testing.T, f *os.File, offset int64) string {
+	t.Helper()
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data[offset:])
+}
+
+func TestTailOffset(t *testing.T) {
+	tests := []struct {
+		content string
+		n       int
+		want    string
+	}{
+		{"a\nb\nc\nd\n", 2, "c\nd\n"},
+		{"a\nb\nc\nd", 2, "c\nd"},
+		{"a\nb\n", 5, "a\nb\n"},
+		{"", 3, ""},
+		{strings.Repeat("x", 5000) + "\n" + strings.Repeat("y", 5000) + "\nz\n", 2, strings.Repeat("y", 5000) + "\nz\n"},
+	}
+
+	for _, tt := range tests {
+		f := writeTemp(t, tt.content)
+		got := rest(t, f, tailOffset(f, int64(len(tt.content)), tt.n))
+		if got != tt.want {
+			t.Errorf("tail %d of %.20q: expected %.20q, got %.20q", tt.n, tt.content, tt.want, got)
+		}
+	}
+}
+
+func TestSinceOffset(t *testing.T) {
+	var b strings.Builder
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 100; i++ {
+		b.WriteString(start.Add(time.Duration(i) * time.Minute).Format(time.RFC3339))
+		b.WriteString(" event\n")
+		if i%10 == 0 {
+			b.WriteString("    at continuation line\n")
+		}
+	}
+	content := b.String()
+	f := writeTemp(t, content)
+
+	timestamp := func(line, source string) (time.Time, bool) {
+		ts, err := time.Parse(time.RFC3339, strings.Fields(line + " x")[0])
+		return ts, err == nil
+	}
+
+	tests := []struct {
+		since time.Time
+		want  string
+	}{
+		{start.Add(-time.Hour), content},
+		{start.Add(42 * time.Minute), "2026-10-16T09:42:00Z event\n"},
+		{start.Add(41*time.Minute + 30*time.Second), "2026-10-16T09:42:00Z event\n"},
+		// A continuation line belongs to the entry before it.
+		{start.Add(51 * time.Minute), "2026-10-16T09:51:00Z event\n"},
+		{start.Add(50 * time.Minute), "2026-10-16T09:50:00Z event\n    at continuation line\n"},
+	}
+
+	for _, tt := range tests {
+		got := rest(t, f, sinceOffset(f, int64(len(content)), tt.since, timestamp))
+		if !strings.HasPrefix(got, tt.want) {
+			t.Errorf("since %s: expected to start with %q, got %.40q", tt.since, tt.want, got)
+		}
+	}
+
+	if got := rest(t, f, sinceOffset(f, int64(len(content)), start.Add(2*time.Hour), timestamp)); got != "" {
+		t.Errorf("since after the last line: expected nothing, got %.40q", got)
+	}
+}
@@ -0,0 +1,79 @@
+package tailer
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log"
+	"os"
+
+	"github.com/atikulmunna/loom/internal/model"
+)
+
+// Stream reads lines from stdin or a named pipe (FIFO) and emits RawLine
+// values. Unlike a Tailer it needs no watcher and keeps no checkpoint:
+// a pipe cannot be re-read, so there is nothing to resume.
+type Stream struct {
+	open   func() (io.ReadCloser, error)
+	source string
+	out    chan model.RawLine
+}
+
+// NewStream creates a Stream over r whose lines are labelled with source.
+func NewStream(r io.Reader, source string) *Stream {
+	return &Stream{
+		open:   func() (io.ReadCloser, error) { return io.NopCloser(r), nil },
+		source: source,
+		out:    make(chan model.RawLine, 512),
+	}
+}
+
+// NewFIFOStream creates a Stream over the named pipe at path. The pipe is
+// opened when the Stream starts, since opening blocks until a writer appears.
+func NewFIFOStream(path, source string) *Stream {
+	return &Stream{
+		open:   func() (io.ReadCloser, error) { return os.Open(path) },
+		source: source,
+		out:    make(chan model.RawLine, 512),
+	}
+}
+
+// IsFIFO reports whether path is a named pipe.
+func IsFIFO(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode()&os.ModeNamedPipe != 0
+}
+
+// Source returns the label attached to the stream's lines.
+func (s *Stream) Source() string {
+	return s.source
+}
+
+// Lines returns the channel where raw log lines are sent.
+func (s *Stream) Lines() <-chan model.RawLine {
+	return s.out
+}
+
+// Start reads lines until EOF or until the context is cancelled.
+func (s *Stream) Start(ctx context.Context) {
+	defer close(s.out)
+
+	r, err := s.open()
+	if err != nil {
+		log.Printf("cannot open %s: %v", s.source, err)
+		return
+	}
+	defer r.Close()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		select {
+		case s.out <- model.RawLine{Text: scanner.Text(), Source: s.source}:
+		case <-ctx.Done():
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("read error on %s: %v", s.source, err)
+	}
+}
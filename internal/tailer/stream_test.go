@@ -0,0 +1,27 @@
+package tailer
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestStreamLines(t *testing.T) {
+	s := NewStream(strings.NewReader("first\nsecond\n"), "kubectl")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Start(ctx)
+
+	var got []string
+	for raw := range s.Lines() {
+		if raw.Source != "kubectl" {
+			t.Errorf("expected source 'kubectl', got %q", raw.Source)
+		}
+		got = append(got, raw.Text)
+	}
+
+	if len(got) != 2 || got[0] != "first" || got[1] != "second" {
+		t.Errorf("expected [first second], got %v", got)
+	}
+}
@@ -14,6 +14,43 @@ import (
 	"github.com/atikulmunna/loom/internal/watcher"
 )
 
+// DefaultRotateGrace is how long a rotated file is kept open by default.
+const DefaultRotateGrace = 5 * time.Second
+
+// Options configures a Tailer. The zero value uses the defaults.
+type Options struct {
+	// RotateGrace is how long a file that was renamed or removed stays open
+	// after rotation, so lines the application still writes to it are read.
+	RotateGrace time.Duration
+
+	// Start positions for files present at startup. When one is set the
+	// checkpoint is ignored for those files; otherwise they resume from the
+	// checkpoint or start at the end.
+	FromBeginning bool
+	TailLines     int       // start at the last N lines, like tail -n
+	Since         time.Time // start at the first line not before Since
+
+	// Until stops reading a file at its first line stamped after Until.
+	Until time.Time
+
+	// NoFollow reads the files present at startup to EOF and stops,
+	// instead of following them for new lines.
+	NoFollow bool
+
+	// Merge makes a NoFollow read emit lines in timestamp order across
+	// files instead of one file after another.
+	Merge bool
+
+	// Timestamp extracts the time of a line from source for Since and
+	// Until. Lines without a timestamp return false.
+	Timestamp func(line, source string) (time.Time, bool)
+}
+
+// hasStart reports whether a start position overrides the checkpoint.
+func (o Options) hasStart() bool {
+	return o.FromBeginning || o.TailLines > 0 || !o.Since.IsZero()
+}
+
 // Tailer reads newly appended lines from watched files and emits RawLine values.
 type Tailer struct {
 	mu     sync.Mutex
@@ -22,23 +59,49 @@ type Tailer struct {
 	ckpt   *Checkpoint
 	events <-chan watcher.Event
 	watch  *watcher.Watcher
+	opts   Options
+
+	// draining holds rotated-away files that are still being read. It is
+	// only touched by the Start goroutine.
+	draining map[string]*trackedFile
+
+	// archives holds compressed files seen while running, with the time
+	// they last changed. Also only touched by the Start goroutine.
+	archives map[string]time.Time
+
+	// rotated holds the last few rotated-away files after they are closed,
+	// to recognise them once compressed. Also only touched by the Start
+	// goroutine.
+	rotated []*trackedFile
+
+	truncations int64
 }
 
 type trackedFile struct {
 	path   string
 	file   *os.File
 	offset int64
-	buf    string // partial line buffer
+	buf    string    // partial line buffer
+	id     FileState // identity saved with the offset
+	done   bool      // reached Until; nothing more is read
+
+	drainUntil time.Time // when a rotated-away file is closed
 }
 
 // New creates a Tailer that reads events from the given Watcher.
-func New(w *watcher.Watcher, ckpt *Checkpoint) *Tailer {
+func New(w *watcher.Watcher, ckpt *Checkpoint, opts Options) *Tailer {
+	if opts.RotateGrace <= 0 {
+		opts.RotateGrace = DefaultRotateGrace
+	}
 	return &Tailer{
-		files:  make(map[string]*trackedFile),
-		out:    make(chan model.RawLine, 512),
-		ckpt:   ckpt,
-		events: w.Events,
-		watch:  w,
+		files:    make(map[string]*trackedFile),
+		out:      make(chan model.RawLine, 512),
+		ckpt:     ckpt,
+		events:   w.Events,
+		watch:    w,
+		opts:     opts,
+		draining: make(map[string]*trackedFile),
+		archives: make(map[string]time.Time),
 	}
 }
 
@@ -47,19 +110,44 @@ func (t *Tailer) Lines() <-chan model.RawLine {
 	return t.out
 }
 
+// Truncations returns how many times a file was truncated in place
+// (e.g. by logrotate's copytruncate) while being tailed.
+func (t *Tailer) Truncations() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.truncations
+}
+
 // Start begins processing watcher events. Blocks until context is cancelled.
 func (t *Tailer) Start(ctx context.Context) {
 	defer close(t.out)
 
-	// Open all initially watched files.
+	// Open all initially watched files and catch up on anything written
+	// since the checkpoint (or since the chosen start position).
+	if t.opts.NoFollow && t.opts.Merge {
+		t.mergeFiles(t.watch.Paths())
+		t.closeAll()
+		return
+	}
 	for _, p := range t.watch.Paths() {
-		t.openFile(p)
+		t.openFile(p, false)
+		t.readNewLines(p)
+	}
+	if t.opts.NoFollow {
+		t.saveCheckpoint()
+		t.closeAll()
+		return
 	}
 
 	// Periodic checkpoint save.
 	saveTicker := time.NewTicker(5 * time.Second)
 	defer saveTicker.Stop()
 
+	// Rotated-away files are polled, since their events no longer carry
+	// the watched path.
+	drainTicker := time.NewTicker(250 * time.Millisecond)
+	defer drainTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -75,6 +163,10 @@ func (t *Tailer) Start(ctx context.Context) {
 
 		case <-saveTicker.C:
 			t.saveCheckpoint()
+
+		case <-drainTicker.C:
+			t.drainRotated()
+			t.readSettledArchives()
 		}
 	}
 }
@@ -83,22 +175,82 @@ func (t *Tailer) Start(ctx context.Context) {
 func (t *Tailer) handleEvent(ev watcher.Event) {
 	switch {
 	case ev.Op&fsnotify.Write != 0:
+		if _, ok := t.archives[ev.Path]; ok {
+			t.archives[ev.Path] = time.Now()
+			return
+		}
 		t.readNewLines(ev.Path)
 
 	case ev.Op&fsnotify.Create != 0:
-		// New file appeared (possibly after rotation).
-		t.openFile(ev.Path)
+		// Archives are read once they are complete.
+		if looksCompressed(ev.Path) {
+			t.archives[ev.Path] = time.Now()
+			return
+		}
+
+		// A rotated file renamed into the glob (app.log to app.log.1) has
+		// been read already: carry on from where reading got to.
+		if t.adoptRenamed(ev.Path) {
+			t.readNewLines(ev.Path)
+			return
+		}
+
+		// New file appeared (discovered, or recreated after rotation):
+		// everything in it is new, so read it from the start.
+		t.openFile(ev.Path, true)
 		t.readNewLines(ev.Path)
 
 	case ev.Op&fsnotify.Remove != 0, ev.Op&fsnotify.Rename != 0:
-		// File rotated or deleted — close and schedule reconnect.
-		t.closeFile(ev.Path)
+		// File rotated or deleted — drain what is left, keep the old
+		// descriptor open for the grace period and schedule reconnect.
+		t.startDrain(ev.Path)
 		go t.reconnect(ev.Path)
 	}
 }
 
+// adoptRenamed reports whether a newly created path is a file that is
+// already open under another name. A rotated-away file being drained moves
+// to the new path and keeps its offset; one still tracked under its old
+// name is left to that.
+func (t *Tailer) adoptRenamed(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	for old, tf := range t.draining {
+		if st, err := tf.file.Stat(); err != nil || !os.SameFile(info, st) {
+			continue
+		}
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if _, exists := t.files[path]; exists {
+			return true
+		}
+		delete(t.draining, old)
+		tf.path = path
+		tf.drainUntil = time.Time{}
+		t.files[path] = tf
+		return true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, tf := range t.files {
+		if st, err := tf.file.Stat(); err == nil && os.SameFile(info, st) {
+			return true
+		}
+	}
+	return false
+}
+
 // openFile opens a file for tailing, resuming from the checkpointed offset.
-func (t *Tailer) openFile(path string) {
+// With fromStart set, the checkpoint is ignored and reading begins at offset 0.
+func (t *Tailer) openFile(path string, fromStart bool) {
+	if kind := compressionOf(path); kind != "" {
+		t.readCompressed(path, kind, !fromStart)
+		return
+	}
+
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
@@ -112,11 +264,21 @@ func (t *Tailer) openFile(path string) {
 		return
 	}
 
-	// Resume from checkpoint or start at end of file.
+	// Resume from checkpoint if it is still the same file, otherwise start
+	// at the beginning of a replaced file or the end of an unknown one.
 	var offset int64
-	if saved, ok := t.ckpt.Get(path); ok {
-		offset = saved
-	} else {
+	saved, ok := t.ckpt.State(path)
+	switch {
+	case fromStart:
+		offset = 0
+	case t.opts.hasStart():
+		offset = t.startOffset(f)
+	case ok && sameFile(f, saved):
+		offset = saved.Offset
+	case ok:
+		log.Printf("%s was replaced since the last checkpoint, reading from start", path)
+		offset = 0
+	default:
 		offset, _ = f.Seek(0, io.SeekEnd)
 	}
 	f.Seek(offset, io.SeekStart)
@@ -125,6 +287,7 @@ func (t *Tailer) openFile(path string) {
 		path:   path,
 		file:   f,
 		offset: offset,
+		id:     identify(f, offset),
 	}
 }
 
@@ -137,45 +300,149 @@ func (t *Tailer) readNewLines(path string) {
 		return
 	}
 	t.mu.Unlock()
+	if tf.done {
+		return
+	}
+
+	t.checkTruncated(tf)
+	t.readFrom(tf)
 
+	// Update checkpoint.
+	pos := tf.offset
+	if tf.id.FingerprintLen < fingerprintSize && pos > tf.id.FingerprintLen {
+		tf.id.Fingerprint, tf.id.FingerprintLen = fingerprint(tf.file, fingerprintSize)
+	}
+	tf.id.Offset = pos
+	t.ckpt.SetState(path, tf.id)
+}
+
+// readFrom reads a file from its current position to EOF, emitting complete
+// lines labelled with the tracked path, and records the new offset.
+func (t *Tailer) readFrom(tf *trackedFile) {
+	start := tf.offset
+	var consumed int64
 	scanner := bufio.NewScanner(tf.file)
-	for scanner.Scan() {
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		adv, tok, err := bufio.ScanLines(data, atEOF)
+		consumed += int64(adv)
+		return adv, tok, err
+	})
+	for {
+		before := consumed
+		if !scanner.Scan() {
+			break
+		}
 		line := tf.buf + scanner.Text()
 		tf.buf = ""
 
-		t.out <- model.RawLine{Text: line, Source: path}
+		if t.pastUntil(line, tf.path) {
+			log.Printf("reached --until in %s, stopped reading", tf.path)
+			tf.done = true
+			tf.offset = start + before
+			return
+		}
+		t.out <- model.RawLine{Text: line, Source: tf.path}
 	}
 
 	// If the last chunk didn't end with a newline, buffer it.
 	if err := scanner.Err(); err != nil {
-		log.Printf("read error on %s: %v", path, err)
+		log.Printf("read error on %s: %v", tf.path, err)
 	}
 
-	// Update offset.
 	pos, _ := tf.file.Seek(0, io.SeekCurrent)
 	tf.offset = pos
-	t.ckpt.Set(path, pos)
 }
 
-// closeFile releases a tracked file.
-func (t *Tailer) closeFile(path string) {
+// pastUntil reports whether a line is stamped after Options.Until.
+func (t *Tailer) pastUntil(line, source string) bool {
+	if t.opts.Until.IsZero() || t.opts.Timestamp == nil {
+		return false
+	}
+	ts, ok := t.opts.Timestamp(line, source)
+	return ok && ts.After(t.opts.Until)
+}
+
+// startDrain moves a rotated-away file out of the tracked set and reads
+// everything written to it so far. The descriptor stays open for the grace
+// period; draining files are never checkpointed.
+func (t *Tailer) startDrain(path string) {
 	t.mu.Lock()
-	defer t.mu.Unlock()
+	tf, ok := t.files[path]
+	delete(t.files, path)
+	t.mu.Unlock()
+	if !ok {
+		return
+	}
 
-	if tf, ok := t.files[path]; ok {
-		tf.file.Close()
-		delete(t.files, path)
+	// A file rotated twice within the grace period replaces the older one.
+	if old, ok := t.draining[path]; ok {
+		t.readFrom(old)
+		t.closeRotated(old)
+	}
+
+	t.readFrom(tf)
+	tf.drainUntil = time.Now().Add(t.opts.RotateGrace)
+	t.draining[path] = tf
+}
+
+// drainRotated reads late writes to rotated-away files and closes those
+// whose grace period has passed.
+func (t *Tailer) drainRotated() {
+	now := time.Now()
+	for path, tf := range t.draining {
+		t.readFrom(tf)
+		if now.After(tf.drainUntil) {
+			t.closeRotated(tf)
+			delete(t.draining, path)
+		}
 	}
 }
 
+// closeRotated closes a rotated-away file and remembers it, forgetting the
+// oldest beyond keepRotated.
+func (t *Tailer) closeRotated(tf *trackedFile) {
+	tf.file.Close()
+	t.rotated = append(t.rotated, tf)
+	if len(t.rotated) > keepRotated {
+		t.rotated = t.rotated[1:]
+	}
+}
+
+// checkTruncated rewinds a file that shrank below the read offset. That
+// happens when logrotate's copytruncate empties the file in place: without
+// the rewind nothing would be read until the file grew past the old offset.
+func (t *Tailer) checkTruncated(tf *trackedFile) {
+	info, err := tf.file.Stat()
+	if err != nil || info.Size() >= tf.offset {
+		return
+	}
+
+	log.Printf("file truncated, reading from start: %s (size %d < offset %d)", tf.path, info.Size(), tf.offset)
+	if _, err := tf.file.Seek(0, io.SeekStart); err != nil {
+		log.Printf("cannot rewind %s: %v", tf.path, err)
+		return
+	}
+	tf.offset = 0
+	tf.buf = ""
+	tf.id = identify(tf.file, 0)
+
+	t.mu.Lock()
+	t.truncations++
+	t.mu.Unlock()
+}
+
 // reconnect polls for a file to reappear after rotation (up to 5 retries).
 func (t *Tailer) reconnect(path string) {
 	for i := 0; i < 5; i++ {
 		time.Sleep(1 * time.Second)
 		if _, err := os.Stat(path); err == nil {
+			// Archives are picked up from their Create event instead.
+			if looksCompressed(path) {
+				return
+			}
 			log.Printf("reconnected to rotated file: %s", path)
 			_ = t.watch.ReWatch(path)
-			t.openFile(path)
+			t.openFile(path, true)
 			return
 		}
 	}
@@ -197,4 +464,8 @@ func (t *Tailer) closeAll() {
 		tf.file.Close()
 		delete(t.files, path)
 	}
+	for path, tf := range t.draining {
+		tf.file.Close()
+		delete(t.draining, path)
+	}
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -30,7 +31,7 @@ func TestTailNewLines(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	tail := New(w, ckpt)
+	tail := New(w, ckpt, Options{})
 
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -66,6 +67,55 @@ func TestTailNewLines(t *testing.T) {
 	time.Sleep(200 * time.Millisecond)
 }
 
+func TestTailAfterCopyTruncate(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "test.log")
+	if err := os.WriteFile(logPath, []byte("old line one\nold line two\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := watcher.New([]string{logPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ckpt, err := NewCheckpoint(filepath.Join(dir, ".loom-state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tail := New(w, ckpt, Options{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go w.Start(ctx)
+	go tail.Start(ctx)
+	time.Sleep(300 * time.Millisecond)
+
+	// Simulate copytruncate: empty the file in place, then write a short line.
+	if err := os.Truncate(logPath, 0); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("new\n")
+	f.Close()
+
+	select {
+	case raw := <-tail.Lines():
+		if raw.Text != "new" {
+			t.Errorf("expected 'new', got %q", raw.Text)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for line after truncation")
+	}
+	if got := tail.Truncations(); got != 1 {
+		t.Errorf("expected 1 truncation, got %d", got)
+	}
+
+	cancel()
+	time.Sleep(200 * time.Millisecond)
+}
+
 func TestCheckpointSaveLoad(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "ckpt.json")
@@ -102,3 +152,245 @@ func TestCheckpointSaveLoad(t *testing.T) {
 		t.Error("expected missing key to return false")
 	}
 }
+
+func TestCheckpointMigratesOffsets(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ckpt.json")
+	if err := os.WriteFile(path, []byte(`{"offsets": {"/var/log/app.log": 42}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := c.Get("/var/log/app.log"); !ok || v != 42 {
+		t.Errorf("expected migrated offset 42, got %d (found=%v)", v, ok)
+	}
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := os.ReadFile(path)
+	if strings.Contains(string(raw), `"offsets"`) {
+		t.Errorf("expected old format to be rewritten, got %s", raw)
+	}
+}
+
+func TestResumeOnlySameFile(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "test.log")
+	if err := os.WriteFile(logPath, []byte("first line\nsecond line\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ckpt, err := NewCheckpoint(filepath.Join(dir, ".loom-state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, _ := os.Open(logPath)
+	ckpt.SetState(logPath, identify(f, 11))
+	f.Close()
+
+	// Same file: resume at the saved offset.
+	w, _ := watcher.New([]string{logPath})
+	tail := New(w, ckpt, Options{})
+	tail.openFile(logPath, false)
+	if got := tail.files[logPath].offset; got != 11 {
+		t.Errorf("expected to resume at 11, got %d", got)
+	}
+	tail.closeAll()
+
+	// Replaced file with the same name: start from the beginning.
+	_ = os.Remove(logPath)
+	if err := os.WriteFile(logPath, []byte("a different file entirely\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tail.openFile(logPath, false)
+	if got := tail.files[logPath].offset; got != 0 {
+		t.Errorf("expected replaced file to start at 0, got %d", got)
+	}
+	tail.closeAll()
+}
+
+func TestDrainRotatedFile(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "test.log")
+	if err := os.WriteFile(logPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := watcher.New([]string{logPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ckpt, err := NewCheckpoint(filepath.Join(dir, ".loom-state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tail := New(w, ckpt, Options{RotateGrace: 2 * time.Second})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go w.Start(ctx)
+	go tail.Start(ctx)
+	time.Sleep(300 * time.Millisecond)
+
+	// The application keeps its descriptor across the rename, as it would
+	// until it is told to reopen its log.
+	app, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Close()
+
+	if err := os.Rename(logPath, logPath+".1"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	_, _ = app.WriteString("late line\n")
+	_ = os.WriteFile(logPath, []byte("new file line\n"), 0644)
+
+	got := map[string]bool{}
+	timeout := time.After(4 * time.Second)
+	for len(got) < 2 {
+		select {
+		case raw := <-tail.Lines():
+			if raw.Source != logPath {
+				t.Errorf("expected source %q, got %q", logPath, raw.Source)
+			}
+			got[raw.Text] = true
+		case <-timeout:
+			t.Fatalf("timed out, got %v", got)
+		}
+	}
+	if !got["late line"] || !got["new file line"] {
+		t.Errorf("expected lines from both files, got %v", got)
+	}
+
+	cancel()
+	time.Sleep(200 * time.Millisecond)
+}
+
+func TestNoFollowStopsAtUntil(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "test.log")
+	content := "09:00 first\n09:30 second\nno timestamp\n10:30 third\n11:00 fourth\n"
+	if err := os.WriteFile(logPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := watcher.New([]string{logPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tail := New(w, nil, Options{
+		FromBeginning: true,
+		NoFollow:      true,
+		Until:         time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC),
+		Timestamp: func(line, source string) (time.Time, bool) {
+			ts, err := time.Parse("15:04", strings.Fields(line)[0])
+			return ts, err == nil
+		},
+	})
+
+	// Start returns, closing Lines, once the file has been read.
+	go tail.Start(context.Background())
+
+	var got []string
+	for raw := range tail.Lines() {
+		got = append(got, raw.Text)
+	}
+	want := []string{"09:00 first", "09:30 second", "no timestamp"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestNoFollowMergesFiles(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.log")
+	b := filepath.Join(dir, "b.log")
+	_ = os.WriteFile(a, []byte("09:00 a1\n09:20 a2\n  continued\n09:40 a3\n"), 0644)
+	_ = os.WriteFile(b, []byte("09:10 b1\n09:30 b2\n09:50 b3\n"), 0644)
+
+	w, err := watcher.New([]string{filepath.Join(dir, "*.log")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tail := New(w, nil, Options{
+		FromBeginning: true,
+		NoFollow:      true,
+		Merge:         true,
+		Timestamp: func(line, source string) (time.Time, bool) {
+			ts, err := time.Parse("15:04", strings.Fields(line)[0])
+			return ts, err == nil
+		},
+	})
+	go tail.Start(context.Background())
+
+	var got []string
+	for raw := range tail.Lines() {
+		got = append(got, strings.TrimSpace(raw.Text))
+	}
+	want := "09:00 a1|09:10 b1|09:20 a2|continued|09:30 b2|09:40 a3|09:50 b3"
+	if strings.Join(got, "|") != want {
+		t.Errorf("expected %s, got %s", want, strings.Join(got, "|"))
+	}
+}
+
+// Rotation that renames a file inside the glob must not read it twice.
+func TestRenameWithinGlob(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(logPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := watcher.New([]string{filepath.Join(dir, "app.log*")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ckpt, err := NewCheckpoint(filepath.Join(dir, ".loom-state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tail := New(w, ckpt, Options{RotateGrace: time.Second})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go w.Start(ctx)
+	go tail.Start(ctx)
+	time.Sleep(300 * time.Millisecond)
+
+	app, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Close()
+	_, _ = app.WriteString("a\nb\nc\n")
+	time.Sleep(300 * time.Millisecond)
+
+	if err := os.Rename(logPath, logPath+".1"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	_, _ = app.WriteString("late\n")
+
+	got := map[string]int{}
+	timeout := time.After(2 * time.Second)
+	for done := false; !done; {
+		select {
+		case raw := <-tail.Lines():
+			got[raw.Text]++
+		case <-timeout:
+			done = true
+		}
+	}
+	for _, line := range []string{"a", "b", "c", "late"} {
+		if got[line] != 1 {
+			t.Errorf("expected %q once, got %v", line, got)
+		}
+	}
+
+	cancel()
+	time.Sleep(200 * time.Millisecond)
+}
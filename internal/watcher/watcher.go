@@ -2,13 +2,23 @@ package watcher
 
 import (
 	"context"
+	"io/fs"
 	"log"
+	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/bmatcuk/doublestar/v4"
 	"github.com/fsnotify/fsnotify"
 )
 
+// forgetAfter is how long a removed or renamed file may stay missing before
+// it is dropped from the watch set.
+const forgetAfter = 10 * time.Second
+
 // Event represents a file change detected by the watcher.
 type Event struct {
 	Path string
@@ -16,14 +26,23 @@ type Event struct {
 }
 
 // Watcher monitors files and directories for changes using OS-level notifications.
+//
+// Rather than watching each file, it watches the parent directories of every
+// glob (recursively for patterns with wildcards in their directory part), so
+// files created after startup that match a pattern are picked up as well.
 type Watcher struct {
-	fsw    *fsnotify.Watcher
-	Events chan Event
-	paths  []string
+	fsw      *fsnotify.Watcher
+	Events   chan Event
+	patterns []string
+
+	mu    sync.RWMutex
+	files map[string]bool // tracked files
+	dirs  map[string]bool // watched directories
+	roots []string        // directories watched recursively
 }
 
 // New creates a Watcher for the given glob patterns.
-// Patterns are expanded at startup and the resulting files are watched.
+// Patterns are expanded at startup; new matches are discovered while running.
 func New(patterns []string) (*Watcher, error) {
 	fsw, err := fsnotify.NewWatcher()
 	if err != nil {
@@ -33,27 +52,80 @@ func New(patterns []string) (*Watcher, error) {
 	w := &Watcher{
 		fsw:    fsw,
 		Events: make(chan Event, 256),
+		files:  make(map[string]bool),
+		dirs:   make(map[string]bool),
 	}
 
 	for _, pattern := range patterns {
-		matches, err := expandGlob(pattern)
+		abs, _ := filepath.Abs(pattern)
+		w.patterns = append(w.patterns, abs)
+
+		matches, err := expandGlob(abs)
 		if err != nil {
 			log.Printf("warning: failed to expand pattern %q: %v", pattern, err)
-			continue
 		}
 		for _, m := range matches {
-			abs, _ := filepath.Abs(m)
-			if err := fsw.Add(abs); err != nil {
-				log.Printf("warning: cannot watch %s: %v", abs, err)
-				continue
-			}
-			w.paths = append(w.paths, abs)
+			w.track(m)
 		}
+
+		w.watchPatternDirs(abs)
 	}
 
 	return w, nil
 }
 
+// watchPatternDirs adds directory watches for a pattern. A pattern with
+// wildcards only in its file name needs just its parent directory; one with
+// wildcards in the directory part (e.g. **) needs its static base, recursively.
+func (w *Watcher) watchPatternDirs(pattern string) {
+	base, rest := doublestar.SplitPattern(filepath.ToSlash(pattern))
+	if !strings.Contains(rest, "/") {
+		w.addDir(filepath.Dir(pattern))
+		return
+	}
+
+	root := filepath.FromSlash(base)
+	w.mu.Lock()
+	w.roots = append(w.roots, root)
+	w.mu.Unlock()
+	w.addTree(root, false)
+}
+
+// addDir starts watching a single directory.
+func (w *Watcher) addDir(dir string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.dirs[dir] {
+		return false
+	}
+	if err := w.fsw.Add(dir); err != nil {
+		log.Printf("warning: cannot watch %s: %v", dir, err)
+		return false
+	}
+	w.dirs[dir] = true
+	return true
+}
+
+// addTree watches dir and every directory below it. With announce set, files
+// already inside that match a pattern are tracked and reported as created
+// (they may have been written before the watch was in place).
+func (w *Watcher) addTree(dir string, announce bool) {
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // unreadable entries are skipped, not fatal
+		}
+		if d.IsDir() {
+			w.addDir(path)
+			return nil
+		}
+		if announce && w.matches(path) && w.track(path) {
+			w.Events <- Event{Path: path, Op: fsnotify.Create}
+		}
+		return nil
+	})
+}
+
 // Start begins listening for file events. It blocks until the context is cancelled.
 func (w *Watcher) Start(ctx context.Context) {
 	defer w.fsw.Close()
@@ -67,14 +139,7 @@ func (w *Watcher) Start(ctx context.Context) {
 			if !ok {
 				return
 			}
-			// Forward relevant events (write, create, remove, rename).
-			switch {
-			case ev.Op&fsnotify.Write != 0,
-				ev.Op&fsnotify.Create != 0,
-				ev.Op&fsnotify.Remove != 0,
-				ev.Op&fsnotify.Rename != 0:
-				w.Events <- Event{Path: ev.Name, Op: ev.Op}
-			}
+			w.handle(ev)
 		case err, ok := <-w.fsw.Errors:
 			if !ok {
 				return
@@ -84,14 +149,117 @@ func (w *Watcher) Start(ctx context.Context) {
 	}
 }
 
+// handle filters a raw fsnotify event and forwards the relevant ones.
+func (w *Watcher) handle(ev fsnotify.Event) {
+	path := ev.Name
+
+	if w.isTracked(path) {
+		// Forward relevant events (write, create, remove, rename).
+		if ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 {
+			w.Events <- Event{Path: path, Op: ev.Op}
+		}
+		if ev.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+			time.AfterFunc(forgetAfter, func() { w.forgetIfGone(path) })
+		}
+		return
+	}
+
+	if ev.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+		w.mu.Lock()
+		delete(w.dirs, path) // fsnotify drops watches on removed directories
+		w.mu.Unlock()
+		return
+	}
+	if ev.Op&fsnotify.Create == 0 {
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	if info.IsDir() {
+		if w.underRoot(path) {
+			w.addTree(path, true)
+		}
+		return
+	}
+	if w.matches(path) && w.track(path) {
+		log.Printf("discovered new file: %s", path)
+		w.Events <- Event{Path: path, Op: ev.Op}
+	}
+}
+
 // Paths returns the list of files currently being watched.
 func (w *Watcher) Paths() []string {
-	return w.paths
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	paths := make([]string, 0, len(w.files))
+	for p := range w.files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
 }
 
 // ReWatch adds a path back to the watcher (used after rotation).
 func (w *Watcher) ReWatch(path string) error {
-	return w.fsw.Add(path)
+	w.track(path)
+	w.addDir(filepath.Dir(path))
+	return nil
+}
+
+// track adds a file to the watch set and reports whether it was new.
+func (w *Watcher) track(path string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.files[path] {
+		return false
+	}
+	w.files[path] = true
+	return true
+}
+
+func (w *Watcher) isTracked(path string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.files[path]
+}
+
+// forgetIfGone drops a file that is still missing after a remove or rename.
+func (w *Watcher) forgetIfGone(path string) {
+	if _, err := os.Stat(path); err == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.files[path] {
+		delete(w.files, path)
+		log.Printf("stopped watching %s: file is gone", path)
+	}
+}
+
+// matches reports whether path matches any of the watched patterns.
+func (w *Watcher) matches(path string) bool {
+	for _, pattern := range w.patterns {
+		if ok, _ := doublestar.PathMatch(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// underRoot reports whether dir lies inside a recursively watched root.
+func (w *Watcher) underRoot(dir string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, root := range w.roots {
+		if rel, err := filepath.Rel(root, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			return true
+		}
+	}
+	return false
 }
 
 // expandGlob resolves a glob pattern to matching file paths.
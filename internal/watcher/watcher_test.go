@@ -0,0 +1,134 @@
+package watcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// waitForCreate returns the first Create event for path, failing after a timeout.
+func waitForCreate(t *testing.T, w *Watcher, path string) {
+	t.Helper()
+	deadline := time.After(3 * time.Second)
+	for {
+		select {
+		case ev := <-w.Events:
+			if ev.Path == path && ev.Op&fsnotify.Create != 0 {
+				return
+			}
+		case <-deadline:
+			t.Fatalf("timed out waiting for create event on %s", path)
+		}
+	}
+}
+
+func TestDiscoversNewFiles(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "app-1.log")
+	if err := os.WriteFile(existing, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := New([]string{filepath.Join(dir, "app-*.log")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Paths(); len(got) != 1 || got[0] != existing {
+		t.Fatalf("expected initial paths [%s], got %v", existing, got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Start(ctx)
+
+	// A matching file is discovered; a non-matching one is ignored.
+	ignored := filepath.Join(dir, "other.txt")
+	created := filepath.Join(dir, "app-7.log")
+	_ = os.WriteFile(ignored, []byte("x\n"), 0644)
+	_ = os.WriteFile(created, []byte("x\n"), 0644)
+
+	waitForCreate(t, w, created)
+	if got := w.Paths(); len(got) != 2 {
+		t.Errorf("expected 2 watched paths, got %v", got)
+	}
+}
+
+func TestDiscoversFilesInNewSubdirectories(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := New([]string{filepath.Join(dir, "**", "*.log")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Start(ctx)
+
+	sub := filepath.Join(dir, "svc", "worker")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond) // let the new directories be watched
+
+	created := filepath.Join(sub, "worker-7.log")
+	_ = os.WriteFile(created, []byte("x\n"), 0644)
+
+	waitForCreate(t, w, created)
+}
+
+func TestForgetsRemovedFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gone.log")
+	_ = os.WriteFile(path, nil, 0644)
+
+	w, err := New([]string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = os.Remove(path)
+	w.forgetIfGone(path)
+
+	if got := w.Paths(); len(got) != 0 {
+		t.Errorf("expected removed file to be dropped, got %v", got)
+	}
+}
+
+func TestRenameWithinPattern(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	_ = os.WriteFile(path, []byte("x\n"), 0644)
+
+	w, err := New([]string{filepath.Join(dir, "app.log*")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Start(ctx)
+
+	// The old name reports the rename before the new one is discovered,
+	// so the tailer can recognise the new name as the same file.
+	rotated := path + ".1"
+	if err := os.Rename(path, rotated); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ev := <-w.Events:
+		if ev.Path != path || ev.Op&fsnotify.Rename == 0 {
+			t.Fatalf("expected a rename of %s first, got %v", path, ev)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for the rename")
+	}
+	waitForCreate(t, w, rotated)
+	if got := w.Paths(); len(got) != 2 {
+		t.Errorf("expected both names to be watched, got %v", got)
+	}
+}